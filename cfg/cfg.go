@@ -0,0 +1,461 @@
+// Package cfg builds the control-flow graphs of the functions from their machine code.
+package cfg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/goccy/binarian/internal/arch"
+)
+
+// Graph is the control-flow graph of a function.
+type Graph struct {
+	// Entry is the block of the first instruction.
+	Entry *Block
+	// Blocks is the basic blocks in the order of their addresses. Blocks[0] is Entry.
+	// The blocks that are not reachable from Entry ( e.g. the destinations of the unresolved indirect jumps ) are included.
+	Blocks []*Block
+	// Padding is the instructions that fill the gaps between the code and are never executed ( e.g. INT3 after RET ).
+	Padding []*arch.Inst
+}
+
+// Block is a sequence of instructions that has a single entry and a single exit.
+type Block struct {
+	// Index is the index of the block in Graph.Blocks.
+	Index int
+	// Addr is the address of the first instruction.
+	Addr  uint64
+	Insts []*arch.Inst
+	// Kind is how the control leaves the block.
+	Kind Kind
+	// Succs is the successors. The branch target is Succs[0] and the next block is Succs[1] if Kind is If.
+	// The successors of a jump table are in the order of their first entries.
+	Succs, Preds []*Block
+	// Table is the destinations of the jump table indexed by the case if Kind is JumpTable.
+	Table []*Block
+}
+
+// Kind is how the control leaves a block.
+type Kind int
+
+const (
+	// Plain falls through to the next block.
+	Plain Kind = iota
+	// Jump jumps to a block of the function.
+	Jump
+	// If jumps to a block of the function or falls through to the next block.
+	If
+	// JumpTable jumps to one of the blocks in the jump table of a switch statement.
+	JumpTable
+	// IndirectJump jumps to an address that is not resolved.
+	IndirectJump
+	// Return returns to the caller.
+	Return
+	// TailCall jumps to another function.
+	TailCall
+	// Trap stops the execution ( e.g. UD2 ).
+	Trap
+	// Exit calls a function that never returns, or reaches the end of the code.
+	Exit
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Plain:
+		return "plain"
+	case Jump:
+		return "jump"
+	case If:
+		return "if"
+	case JumpTable:
+		return "jumptable"
+	case IndirectJump:
+		return "indirect"
+	case Return:
+		return "return"
+	case TailCall:
+		return "tailcall"
+	case Trap:
+		return "trap"
+	case Exit:
+		return "exit"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Option is an option of Build.
+type Option func(*config)
+
+type config struct {
+	read     arch.ReadMemory
+	noReturn func(addr uint64) bool
+}
+
+// WithMemory gives the memory of the binary to read the jump tables.
+// The indirect jumps through the jump tables are not resolved without it.
+func WithMemory(read arch.ReadMemory) Option {
+	return func(cfg *config) {
+		cfg.read = read
+	}
+}
+
+// WithNoReturn gives the functions that never return by their entries.
+// The compiler places the next block right after the calls of them, so the blocks must not fall through there.
+func WithNoReturn(noReturn func(addr uint64) bool) Option {
+	return func(cfg *config) {
+		cfg.noReturn = noReturn
+	}
+}
+
+// noReturnFuncs is the functions that never return. They are listed explicitly because some functions with the same prefixes return
+// ( e.g. runtime.panicCheck1 returns unless the panic is in the runtime ).
+var noReturnFuncs = map[string]bool{
+	"runtime.gopanic":    true,
+	"runtime.throw":      true,
+	"runtime.fatal":      true,
+	"runtime.fatalthrow": true,
+	"runtime.fatalpanic": true,
+	"runtime.Goexit":     true,
+	"runtime.goexit1":    true,
+
+	// the panics of the checks generated by the compiler.
+	"runtime.panicIndex":               true,
+	"runtime.panicIndexU":              true,
+	"runtime.panicSliceAlen":           true,
+	"runtime.panicSliceAlenU":          true,
+	"runtime.panicSliceAcap":           true,
+	"runtime.panicSliceAcapU":          true,
+	"runtime.panicSliceB":              true,
+	"runtime.panicSliceBU":             true,
+	"runtime.panicSlice3Alen":          true,
+	"runtime.panicSlice3AlenU":         true,
+	"runtime.panicSlice3Acap":          true,
+	"runtime.panicSlice3AcapU":         true,
+	"runtime.panicSlice3B":             true,
+	"runtime.panicSlice3BU":            true,
+	"runtime.panicSlice3C":             true,
+	"runtime.panicSlice3CU":            true,
+	"runtime.panicSliceConvert":        true,
+	"runtime.panicBounds":              true,
+	"runtime.panicBounds32":            true,
+	"runtime.panicBounds32X":           true,
+	"runtime.panicBounds64":            true,
+	"runtime.panicExtend":              true,
+	"runtime.panicExtendIndex":         true,
+	"runtime.panicExtendIndexU":        true,
+	"runtime.panicExtendSliceAlen":     true,
+	"runtime.panicExtendSliceAlenU":    true,
+	"runtime.panicExtendSliceAcap":     true,
+	"runtime.panicExtendSliceAcapU":    true,
+	"runtime.panicExtendSliceB":        true,
+	"runtime.panicExtendSliceBU":       true,
+	"runtime.panicExtendSlice3Alen":    true,
+	"runtime.panicExtendSlice3AlenU":   true,
+	"runtime.panicExtendSlice3Acap":    true,
+	"runtime.panicExtendSlice3AcapU":   true,
+	"runtime.panicExtendSlice3B":       true,
+	"runtime.panicExtendSlice3BU":      true,
+	"runtime.panicExtendSlice3C":       true,
+	"runtime.panicExtendSlice3CU":      true,
+	"runtime.goPanicIndex":             true,
+	"runtime.goPanicIndexU":            true,
+	"runtime.goPanicSliceAlen":         true,
+	"runtime.goPanicSliceAlenU":        true,
+	"runtime.goPanicSliceAcap":         true,
+	"runtime.goPanicSliceAcapU":        true,
+	"runtime.goPanicSliceB":            true,
+	"runtime.goPanicSliceBU":           true,
+	"runtime.goPanicSlice3Alen":        true,
+	"runtime.goPanicSlice3AlenU":       true,
+	"runtime.goPanicSlice3Acap":        true,
+	"runtime.goPanicSlice3AcapU":       true,
+	"runtime.goPanicSlice3B":           true,
+	"runtime.goPanicSlice3BU":          true,
+	"runtime.goPanicSlice3C":           true,
+	"runtime.goPanicSlice3CU":          true,
+	"runtime.goPanicSliceConvert":      true,
+	"runtime.goPanicExtendIndex":       true,
+	"runtime.goPanicExtendIndexU":      true,
+	"runtime.goPanicExtendSliceAlen":   true,
+	"runtime.goPanicExtendSliceAlenU":  true,
+	"runtime.goPanicExtendSliceAcap":   true,
+	"runtime.goPanicExtendSliceAcapU":  true,
+	"runtime.goPanicExtendSliceB":      true,
+	"runtime.goPanicExtendSliceBU":     true,
+	"runtime.goPanicExtendSlice3Alen":  true,
+	"runtime.goPanicExtendSlice3AlenU": true,
+	"runtime.goPanicExtendSlice3Acap":  true,
+	"runtime.goPanicExtendSlice3AcapU": true,
+	"runtime.goPanicExtendSlice3B":     true,
+	"runtime.goPanicExtendSlice3BU":    true,
+	"runtime.goPanicExtendSlice3C":     true,
+	"runtime.goPanicExtendSlice3CU":    true,
+	"runtime.panicdivide":              true,
+	"runtime.panicshift":               true,
+	"runtime.panicoverflow":            true,
+	"runtime.panicfloat":               true,
+	"runtime.panicmem":                 true,
+	"runtime.panicmemAddr":             true,
+	"runtime.panicwrap":                true,
+	"runtime.panicdottypeE":            true,
+	"runtime.panicdottypeI":            true,
+	"runtime.panicnildottype":          true,
+	"runtime.panicunsafeslicelen":      true,
+	"runtime.panicunsafeslicelen1":     true,
+	"runtime.panicunsafeslicenilptr":   true,
+	"runtime.panicunsafeslicenilptr1":  true,
+	"runtime.panicunsafestringlen":     true,
+	"runtime.panicunsafestringnilptr":  true,
+	"runtime.panicrangestate":          true,
+}
+
+// NoReturnFunc reports whether the function of the symbol name never returns.
+func NoReturnFunc(name string) bool {
+	return noReturnFuncs[name]
+}
+
+// Build builds the control-flow graph of a function.
+// insts is the decoded instructions of the function from its entry to end.
+// A jump out of the function is a tail call, and a conditional jump out of the function is regarded as an ordinary instruction.
+// arc must implement arch.Flow ( amd64, 386 and arm64 ). Otherwise the error wraps arch.ErrUnsupported.
+func Build(arc arch.Arch, insts []*arch.Inst, end uint64, opts ...Option) (*Graph, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	flow, ok := arc.(arch.Flow)
+	if !ok {
+		return nil, fmt.Errorf("failed to build control-flow graph: %w %s", arch.ErrUnsupported, arc.Name())
+	}
+	if len(insts) == 0 {
+		return nil, fmt.Errorf("failed to build control-flow graph: no instructions")
+	}
+	b := &builder{
+		cfg:     cfg,
+		arc:     arc,
+		flow:    flow,
+		insts:   insts,
+		entry:   insts[0].PC,
+		end:     end,
+		padding: make([]bool, len(insts)),
+		kinds:   make([]Kind, len(insts)),
+		targets: make([][]int, len(insts)),
+	}
+	return b.build(), nil
+}
+
+type builder struct {
+	cfg   *config
+	arc   arch.Arch
+	flow  arch.Flow
+	insts []*arch.Inst
+	entry uint64
+	end   uint64
+	// padding, kinds and targets are indexed by the instructions.
+	// kinds[i] is Plain if insts[i] does not end a block, and targets[i] is the indices of its destinations.
+	padding []bool
+	kinds   []Kind
+	targets [][]int
+}
+
+func (b *builder) build() *Graph {
+	for i := range b.insts {
+		b.kinds[i], b.targets[i] = b.exit(i)
+	}
+	b.markPadding()
+	leaders := make([]bool, len(b.insts))
+	for i := range b.insts {
+		if b.padding[i] {
+			continue
+		}
+		if i == 0 || b.padding[i-1] || b.kinds[i-1] != Plain {
+			leaders[i] = true
+		}
+		for _, target := range b.targets[i] {
+			leaders[target] = true
+		}
+	}
+	g := &Graph{}
+	blockOf := make([]*Block, len(b.insts))
+	for i, inst := range b.insts {
+		if b.padding[i] {
+			g.Padding = append(g.Padding, inst)
+			continue
+		}
+		if leaders[i] {
+			g.Blocks = append(g.Blocks, &Block{Index: len(g.Blocks), Addr: inst.PC})
+		}
+		block := g.Blocks[len(g.Blocks)-1]
+		block.Insts = append(block.Insts, inst)
+		blockOf[i] = block
+	}
+	g.Entry = g.Blocks[0]
+	for i, block := range g.Blocks {
+		last := b.instIndex(block.Insts[len(block.Insts)-1].PC)
+		var next *Block
+		if i+1 < len(g.Blocks) && last+1 < len(b.insts) && blockOf[last+1] == g.Blocks[i+1] {
+			next = g.Blocks[i+1]
+		}
+		block.Kind = b.kinds[last]
+		switch block.Kind {
+		case Plain:
+			if next == nil {
+				// falls into the padding or out of the code.
+				block.Kind = Exit
+				continue
+			}
+			block.addSucc(next)
+		case Jump:
+			block.addSucc(blockOf[b.targets[last][0]])
+		case If:
+			block.addSucc(blockOf[b.targets[last][0]])
+			if next != nil {
+				block.addSucc(next)
+			}
+		case JumpTable:
+			for _, target := range b.targets[last] {
+				block.Table = append(block.Table, blockOf[target])
+				block.addSucc(blockOf[target])
+			}
+		}
+	}
+	return g
+}
+
+func (block *Block) addSucc(succ *Block) {
+	for _, s := range block.Succs {
+		if s == succ {
+			return
+		}
+	}
+	block.Succs = append(block.Succs, succ)
+	succ.Preds = append(succ.Preds, block)
+}
+
+// exit returns how the control leaves insts[i] and the indices of its destinations in the function.
+func (b *builder) exit(i int) (Kind, []int) {
+	inst := b.insts[i]
+	switch b.flow.Flow(inst) {
+	case arch.FlowJump:
+		if target, ok := b.branchTarget(inst); ok {
+			return Jump, []int{target}
+		}
+		return TailCall, nil
+	case arch.FlowCondJump:
+		if target, ok := b.branchTarget(inst); ok {
+			return If, []int{target}
+		}
+	case arch.FlowIndirectJump:
+		if b.cfg.read == nil {
+			return IndirectJump, nil
+		}
+		addrs, ok := b.flow.JumpTable(b.insts, i, b.cfg.read)
+		if !ok {
+			return IndirectJump, nil
+		}
+		targets := make([]int, 0, len(addrs))
+		for _, addr := range addrs {
+			target := b.instIndex(addr)
+			if addr < b.entry || addr >= b.end || target < 0 {
+				// the table is not of this function.
+				return IndirectJump, nil
+			}
+			targets = append(targets, target)
+		}
+		return JumpTable, targets
+	case arch.FlowReturn:
+		return Return, nil
+	case arch.FlowTrap:
+		return Trap, nil
+	case arch.FlowNext:
+		if target, ok := b.arc.CallTarget(inst); ok && b.cfg.noReturn != nil && b.cfg.noReturn(target) {
+			return Exit, nil
+		}
+	}
+	return Plain, nil
+}
+
+// markPadding marks the runs of the padding instructions that the control never reaches.
+func (b *builder) markPadding() {
+	targeted := make([]bool, len(b.insts))
+	for _, targets := range b.targets {
+		for _, target := range targets {
+			targeted[target] = true
+		}
+	}
+	for i, inst := range b.insts {
+		if i == 0 || targeted[i] || !b.flow.Padding(inst) {
+			continue
+		}
+		if !b.padding[i-1] && b.kinds[i-1] == Plain {
+			// falls through to inst.
+			continue
+		}
+		b.padding[i] = true
+	}
+	for i := range b.insts {
+		if b.padding[i] {
+			b.kinds[i], b.targets[i] = Plain, nil
+		}
+	}
+}
+
+// branchTarget returns the index of the destination of the jump inst if it is an instruction of the function.
+func (b *builder) branchTarget(inst *arch.Inst) (int, bool) {
+	target, ok := b.arc.BranchTarget(inst)
+	if !ok || target < b.entry || target >= b.end {
+		return 0, false
+	}
+	i := b.instIndex(target)
+	return i, i >= 0
+}
+
+// instIndex returns the index of the instruction at pc, or -1 if no instruction starts at pc.
+func (b *builder) instIndex(pc uint64) int {
+	i := sort.Search(len(b.insts), func(i int) bool { return b.insts[i].PC >= pc })
+	if i == len(b.insts) || b.insts[i].PC != pc {
+		return -1
+	}
+	return i
+}
+
+// Block returns the block that contains the instruction at pc, or nil if there is no such block.
+func (g *Graph) Block(pc uint64) *Block {
+	i := sort.Search(len(g.Blocks), func(i int) bool { return g.Blocks[i].Addr > pc })
+	if i == 0 {
+		return nil
+	}
+	block := g.Blocks[i-1]
+	last := block.Insts[len(block.Insts)-1]
+	if pc >= last.PC+uint64(last.Len) {
+		return nil
+	}
+	return block
+}
+
+func (g *Graph) String() string {
+	var b strings.Builder
+	for _, block := range g.Blocks {
+		fmt.Fprintf(&b, "%d: %#x %s", block.Index, block.Addr, block.Kind)
+		if len(block.Preds) > 0 {
+			fmt.Fprintf(&b, " preds:%s", blockIndices(block.Preds))
+		}
+		if len(block.Succs) > 0 {
+			fmt.Fprintf(&b, " succs:%s", blockIndices(block.Succs))
+		}
+		fmt.Fprintf(&b, " ( %d instructions )\n", len(block.Insts))
+	}
+	if len(g.Padding) > 0 {
+		fmt.Fprintf(&b, "padding: %d instructions\n", len(g.Padding))
+	}
+	return b.String()
+}
+
+func blockIndices(blocks []*Block) string {
+	var b strings.Builder
+	for _, block := range blocks {
+		fmt.Fprintf(&b, " %d", block.Index)
+	}
+	return b.String()
+}
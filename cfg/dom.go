@@ -0,0 +1,182 @@
+package cfg
+
+// DomTree is the dominator tree or the post-dominator tree of a Graph.
+// It has the blocks reachable from the entry, and the post-dominator tree has only the blocks that reach an exit of the function.
+type DomTree struct {
+	idom     []*Block
+	children [][]*Block
+	roots    []*Block
+	// in and out are the numbers of the blocks in the depth-first traversal of the tree. They are zero if the block is not in the tree.
+	in, out []int
+}
+
+// Dominators returns the dominator tree of g. Its root is the entry.
+// The algorithm is of Cooper et al. ( A Simple, Fast Dominance Algorithm ).
+func (g *Graph) Dominators() *DomTree {
+	return newDomTree(g, false)
+}
+
+// PostDominators returns the post-dominator tree of g.
+// The exits of the function ( the blocks without successors ) are the roots, because the function can leave from any of them.
+func (g *Graph) PostDominators() *DomTree {
+	return newDomTree(g, true)
+}
+
+// Idom returns the immediate dominator ( or post-dominator ) of b. It returns nil if b is a root or not in the tree.
+func (t *DomTree) Idom(b *Block) *Block {
+	return t.idom[b.Index]
+}
+
+// Children returns the blocks immediately dominated ( or post-dominated ) by b.
+func (t *DomTree) Children(b *Block) []*Block {
+	return t.children[b.Index]
+}
+
+// Roots returns the roots of the tree.
+func (t *DomTree) Roots() []*Block {
+	return t.roots
+}
+
+// Dominates reports whether a dominates ( or post-dominates ) b. A block dominates itself.
+func (t *DomTree) Dominates(a, b *Block) bool {
+	if t.in[a.Index] == 0 || t.in[b.Index] == 0 {
+		return false
+	}
+	return t.in[a.Index] <= t.in[b.Index] && t.out[b.Index] <= t.out[a.Index]
+}
+
+func newDomTree(g *Graph, post bool) *DomTree {
+	// the node n is the virtual root that leads to the entry, or that the exits lead to.
+	n := len(g.Blocks)
+	reachable := g.reachable()
+	succs := make([][]int, n+1)
+	preds := make([][]int, n+1)
+	addEdge := func(from, to int) {
+		if post {
+			from, to = to, from
+		}
+		succs[from] = append(succs[from], to)
+		preds[to] = append(preds[to], from)
+	}
+	if !post {
+		addEdge(n, g.Entry.Index)
+	}
+	for _, b := range g.Blocks {
+		if !reachable[b.Index] {
+			continue
+		}
+		for _, succ := range b.Succs {
+			addEdge(b.Index, succ.Index)
+		}
+		if post && len(b.Succs) == 0 {
+			addEdge(b.Index, n)
+		}
+	}
+
+	// number the nodes in postorder from the root.
+	order := make([]int, 0, n+1)
+	postNum := make([]int, n+1)
+	for i := range postNum {
+		postNum[i] = -1
+	}
+	visited := make([]bool, n+1)
+	var visit func(i int)
+	visit = func(i int) {
+		visited[i] = true
+		for _, succ := range succs[i] {
+			if !visited[succ] {
+				visit(succ)
+			}
+		}
+		postNum[i] = len(order)
+		order = append(order, i)
+	}
+	visit(n)
+
+	idom := make([]int, n+1)
+	for i := range idom {
+		idom[i] = -1
+	}
+	idom[n] = n
+	intersect := func(a, b int) int {
+		for a != b {
+			for postNum[a] < postNum[b] {
+				a = idom[a]
+			}
+			for postNum[b] < postNum[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// in reverse postorder except the root.
+		for k := len(order) - 2; k >= 0; k-- {
+			i := order[k]
+			newIdom := -1
+			for _, pred := range preds[i] {
+				if idom[pred] < 0 {
+					continue
+				}
+				if newIdom < 0 {
+					newIdom = pred
+				} else {
+					newIdom = intersect(pred, newIdom)
+				}
+			}
+			if idom[i] != newIdom {
+				idom[i] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	t := &DomTree{
+		idom:     make([]*Block, n),
+		children: make([][]*Block, n),
+		in:       make([]int, n),
+		out:      make([]int, n),
+	}
+	for _, b := range g.Blocks {
+		switch d := idom[b.Index]; {
+		case d < 0:
+		case d == n:
+			t.roots = append(t.roots, b)
+		default:
+			t.idom[b.Index] = g.Blocks[d]
+			t.children[d] = append(t.children[d], b)
+		}
+	}
+	num := 0
+	var number func(b *Block)
+	number = func(b *Block) {
+		num++
+		t.in[b.Index] = num
+		for _, child := range t.children[b.Index] {
+			number(child)
+		}
+		num++
+		t.out[b.Index] = num
+	}
+	for _, root := range t.roots {
+		number(root)
+	}
+	return t
+}
+
+// reachable returns whether the blocks are reachable from the entry by their indices.
+func (g *Graph) reachable() []bool {
+	reachable := make([]bool, len(g.Blocks))
+	var visit func(b *Block)
+	visit = func(b *Block) {
+		reachable[b.Index] = true
+		for _, succ := range b.Succs {
+			if !reachable[succ.Index] {
+				visit(succ)
+			}
+		}
+	}
+	visit(g.Entry)
+	return reachable
+}
@@ -0,0 +1,132 @@
+package cfg
+
+import (
+	"sort"
+)
+
+// Loop is a natural loop of a Graph.
+type Loop struct {
+	// Header is the block that dominates the blocks of the loop.
+	Header *Block
+	// Blocks is the blocks of the loop including Header in the order of their addresses.
+	Blocks []*Block
+	// Latches is the blocks that jump back to Header.
+	Latches []*Block
+	// Parent is the innermost loop that contains the loop. It is nil for the outermost loops.
+	Parent   *Loop
+	Children []*Loop
+	// Depth is the nesting depth of the loop. The outermost loops are 1.
+	Depth int
+}
+
+// Contains reports whether b is a block of the loop.
+func (l *Loop) Contains(b *Block) bool {
+	i := sort.Search(len(l.Blocks), func(i int) bool { return l.Blocks[i].Addr >= b.Addr })
+	return i < len(l.Blocks) && l.Blocks[i] == b
+}
+
+// Loops returns the natural loops of g in the order of the addresses of their headers.
+// A natural loop is found by a back edge whose destination dominates its source, and the back edges to the same header make a loop.
+// The irreducible cycles that have no such header are not loops.
+func (g *Graph) Loops() []*Loop {
+	dom := g.Dominators()
+	reachable := g.reachable()
+	byHeader := map[*Block]*Loop{}
+	var loops []*Loop
+	for _, b := range g.Blocks {
+		if !reachable[b.Index] {
+			continue
+		}
+		for _, succ := range b.Succs {
+			if !dom.Dominates(succ, b) {
+				continue
+			}
+			loop, exists := byHeader[succ]
+			if !exists {
+				loop = &Loop{Header: succ}
+				byHeader[succ] = loop
+				loops = append(loops, loop)
+			}
+			loop.Latches = append(loop.Latches, b)
+		}
+	}
+	for _, loop := range loops {
+		// the blocks that reach the latches without passing the header.
+		in := map[*Block]bool{loop.Header: true}
+		stack := append([]*Block{}, loop.Latches...)
+		for len(stack) > 0 {
+			b := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if in[b] {
+				continue
+			}
+			in[b] = true
+			for _, pred := range b.Preds {
+				if reachable[pred.Index] && !in[pred] {
+					stack = append(stack, pred)
+				}
+			}
+		}
+		for _, b := range g.Blocks {
+			if in[b] {
+				loop.Blocks = append(loop.Blocks, b)
+			}
+		}
+	}
+	sort.Slice(loops, func(i, j int) bool {
+		return loops[i].Header.Addr < loops[j].Header.Addr
+	})
+	for _, loop := range loops {
+		// the parent is the smallest loop that contains the header.
+		for _, outer := range loops {
+			if outer == loop || !outer.Contains(loop.Header) || len(outer.Blocks) <= len(loop.Blocks) {
+				continue
+			}
+			if loop.Parent == nil || len(outer.Blocks) < len(loop.Parent.Blocks) {
+				loop.Parent = outer
+			}
+		}
+	}
+	for _, loop := range loops {
+		if loop.Parent != nil {
+			loop.Parent.Children = append(loop.Parent.Children, loop)
+		}
+	}
+	var setDepth func(loop *Loop, depth int)
+	setDepth = func(loop *Loop, depth int) {
+		loop.Depth = depth
+		for _, child := range loop.Children {
+			setDepth(child, depth+1)
+		}
+	}
+	for _, loop := range loops {
+		if loop.Parent == nil {
+			setDepth(loop, 1)
+		}
+	}
+	return loops
+}
+
+// Complexity returns the cyclomatic complexity of the blocks reachable from the entry.
+// It is E - N + 2 of the graph whose exits ( the blocks without successors ) are connected to a single virtual exit,
+// so every successor of a block beyond the first adds one.
+func (g *Graph) Complexity() int {
+	reachable := g.reachable()
+	var nodes, edges, exits int
+	for _, b := range g.Blocks {
+		if !reachable[b.Index] {
+			continue
+		}
+		nodes++
+		edges += len(b.Succs)
+		if len(b.Succs) == 0 {
+			exits++
+		}
+	}
+	if exits == 0 {
+		// the function never returns ( e.g. an infinite loop ).
+		return edges - nodes + 2
+	}
+	// the virtual exit adds a node and an edge of each exit.
+	return (edges + exits) - (nodes + 1) + 2
+}
@@ -0,0 +1,662 @@
+package file
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"regexp"
+	"strings"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/arch"
+	"github.com/goccy/binarian/reflect"
+	binarytypes "github.com/goccy/binarian/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// unreachableMethod is the function that the linker puts in the method table of itabs instead of the removed methods.
+const unreachableMethod = "runtime.unreachableMethod"
+
+// CallGraphAlgorithm is the algorithm that selects the callees of interface method calls in the call graph.
+type CallGraphAlgorithm int
+
+const (
+	// CHA ( class hierarchy analysis ) resolves an interface method call to the methods of all types in the binary
+	// whose method sets satisfy the interface type. The graph has a node for every function in the binary.
+	CHA CallGraphAlgorithm = iota
+	// RTA ( rapid type analysis ) resolves an interface method call only to the methods of the types that
+	// reachable functions instantiate, that is, refer to their itabs or type descriptors.
+	// The graph has only the functions reachable from main.main and the package initializers.
+	RTA
+)
+
+func (alg CallGraphAlgorithm) String() string {
+	switch alg {
+	case CHA:
+		return "cha"
+	case RTA:
+		return "rta"
+	}
+	return fmt.Sprintf("CallGraphAlgorithm(%d)", int(alg))
+}
+
+// CallGraphOption is an option of CallGraph.
+type CallGraphOption func(*callGraphConfig)
+
+type callGraphConfig struct {
+	algorithm CallGraphAlgorithm
+}
+
+// WithAlgorithm specifies the algorithm to build the call graph. The default is CHA.
+func WithAlgorithm(alg CallGraphAlgorithm) CallGraphOption {
+	return func(cfg *callGraphConfig) {
+		cfg.algorithm = alg
+	}
+}
+
+// callEdge is an edge of the call graph found in the binary.
+type callEdge struct {
+	caller *Function
+	site   ssa.CallInstruction
+	callee *Function
+	// typ is the concrete type whose method is the callee of an interface method call. It is nil for other calls.
+	typ reflect.Type
+}
+
+// callGraph returns the call graph of the binary rooted at main.main.
+// The site of each edge tells how the callee is called, and edge.Description() describes it.
+//
+//   - static calls have a static function call site ( *ssa.Call ).
+//   - interface method calls have an edge to each candidate implementation with a dynamic method call site
+//     ( *ssa.Call whose Common().IsInvoke() reports true ).
+//   - the function that creates a func literal has an edge to it. The site is *ssa.Go if the literal runs on a new goroutine,
+//     *ssa.Defer if it is deferred, or otherwise *ssa.Call of the closure ( *ssa.MakeClosure ).
+//
+// The candidates of interface method calls depend on the algorithm ( see CallGraphAlgorithm ).
+//
+// The sites are synthesized from the machine code, and the binary has no SSA body of the caller that they belong to.
+// So only Common() and Pos() ( token.NoPos ) of edge.Site are available, and Block() returns nil.
+// Parent() and String() of the site must not be called, because they dereference the block. Use edge.Caller instead.
+func (a *analyzer) callGraph(opts ...CallGraphOption) (*callgraph.Graph, error) {
+	cfg := &callGraphConfig{algorithm: CHA}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	var mainFunc *Function
+	funcBySSA := make(map[*ssa.Function]*Function, len(funcs))
+	for _, fn := range funcs {
+		funcBySSA[fn.SSAFunc] = fn
+		if mainFunc == nil && fn.SymFunc.Name == "main.main" {
+			mainFunc = fn
+		}
+	}
+	if mainFunc == nil {
+		return nil, fmt.Errorf("failed to find main function")
+	}
+	var edges []*callEdge
+	for _, fn := range funcs {
+		for _, callee := range fn.Callee {
+			edges = append(edges, &callEdge{
+				caller: fn,
+				site:   &ssa.Call{Call: ssa.CallCommon{Value: callee}},
+				callee: funcBySSA[callee],
+			})
+		}
+	}
+	arc, err := arch.Lookup(a.obj.arch())
+	if err != nil {
+		return nil, err
+	}
+	// indirect calls are not resolved on the architectures that do not implement arch.Indirect.
+	indirect, _ := arc.(arch.Indirect)
+	if indirect != nil {
+		ifaceEdges, err := a.interfaceCallEdges(funcs, arc, indirect)
+		if err != nil {
+			return nil, err
+		}
+		litEdges, err := a.funcLiteralEdges(funcs, arc, indirect)
+		if err != nil {
+			return nil, err
+		}
+		edges = append(append(edges, ifaceEdges...), litEdges...)
+	}
+	graph := callgraph.New(mainFunc.SSAFunc)
+	switch cfg.algorithm {
+	case CHA:
+		for _, fn := range funcs {
+			graph.CreateNode(fn.SSAFunc)
+		}
+	case RTA:
+		var roots []*Function
+		for _, fn := range funcs {
+			if fn == mainFunc || initFuncPattern.MatchString(fn.SymFunc.Name) {
+				roots = append(roots, fn)
+			}
+		}
+		edges, err = a.rta(roots, edges, indirect)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported call graph algorithm %s", cfg.algorithm)
+	}
+	type key struct {
+		caller *Function
+		site   ssa.CallInstruction
+		callee *Function
+	}
+	// the method of T and *T shares the edge.
+	seen := make(map[key]struct{}, len(edges))
+	for _, edge := range edges {
+		k := key{caller: edge.caller, site: edge.site, callee: edge.callee}
+		if _, exists := seen[k]; exists {
+			continue
+		}
+		seen[k] = struct{}{}
+		callgraph.AddEdge(graph.CreateNode(edge.caller.SSAFunc), edge.site, graph.CreateNode(edge.callee.SSAFunc))
+	}
+	return graph, nil
+}
+
+// initFuncPattern matches the names of package initializers ( pkg.init, pkg.init.0 ).
+// They are not called directly but through the init tasks, so they are the roots of the call graph in addition to main.main.
+var initFuncPattern = regexp.MustCompile(`\.init(\.\d+)?$`)
+
+// rta returns the edges reachable from roots. An edge of an interface method call is reachable only after
+// a reachable function instantiates its concrete type. indirect is used to find the types that functions refer to, and it can be nil.
+func (a *analyzer) rta(roots []*Function, edges []*callEdge, indirect arch.Indirect) ([]*callEdge, error) {
+	typeByAddr := map[uint64]reflect.Type{}
+	if indirect != nil {
+		impls, err := a.implementations()
+		if err != nil {
+			return nil, err
+		}
+		for _, impl := range impls.Types {
+			typeByAddr[uint64(impl.Type.Addr())] = impl.Type
+		}
+		itabs, err := a.itabs()
+		if err != nil {
+			return nil, err
+		}
+		for _, itab := range itabs {
+			typeByAddr[itab.Addr] = itab.Type
+		}
+	}
+	edgesByCaller := map[*Function][]*callEdge{}
+	for _, edge := range edges {
+		edgesByCaller[edge.caller] = append(edgesByCaller[edge.caller], edge)
+	}
+	var (
+		reachable    = map[*Function]struct{}{}
+		runtimeTypes = map[uintptr]struct{}{}
+		// pending is the edges of interface method calls from reachable functions waiting for the instantiation of their types.
+		pending  = map[uintptr][]*callEdge{}
+		queue    []*Function
+		selected []*callEdge
+	)
+	visit := func(fn *Function) {
+		if _, exists := reachable[fn]; exists {
+			return
+		}
+		reachable[fn] = struct{}{}
+		queue = append(queue, fn)
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	for len(queue) > 0 {
+		fn := queue[0]
+		queue = queue[1:]
+		if indirect != nil {
+			for i := range fn.Inst {
+				addr, ok := indirect.AddrRef(fn.Inst, i)
+				if !ok {
+					continue
+				}
+				typ, exists := typeByAddr[addr]
+				if !exists {
+					continue
+				}
+				if _, exists := runtimeTypes[typ.Addr()]; exists {
+					continue
+				}
+				runtimeTypes[typ.Addr()] = struct{}{}
+				for _, edge := range pending[typ.Addr()] {
+					selected = append(selected, edge)
+					visit(edge.callee)
+				}
+				delete(pending, typ.Addr())
+			}
+		}
+		for _, edge := range edgesByCaller[fn] {
+			if edge.typ != nil {
+				if _, exists := runtimeTypes[edge.typ.Addr()]; !exists {
+					pending[edge.typ.Addr()] = append(pending[edge.typ.Addr()], edge)
+					continue
+				}
+			}
+			selected = append(selected, edge)
+			visit(edge.callee)
+		}
+	}
+	return selected, nil
+}
+
+// interfaceCallResolver resolves the callee candidates of interface method calls.
+//
+// An interface method call loads the callee from the method table of the itab ( itab.fun[i] ), so the method index is known from the displacement of the load.
+// The interface type is known from the itab that reaches the load. The base register of the load is traced backward through the register moves
+// and the stack slots to the instruction that materializes the address of the itab ( e.g. LEAQ go:itab.*T,I(SB), AX ).
+// If the itab is an argument of the function, it is traced in the direct callers before the calls of the function.
+// For each of the interface types of the itabs, the candidates are
+//
+//   - the methods in the itabs of the interface type ( the conversions in the binary )
+//   - the methods of the concrete types that implement the interface type ( class hierarchy analysis, for the itabs created at run time )
+//
+// The call has no candidates if no itab reaches it ( e.g. the interface value is loaded from the heap or returned by a type assertion ).
+// The calls of the func values in struct fields load the callee in the same way, but no itab reaches them either.
+type interfaceCallResolver struct {
+	arc          arch.Arch
+	indirect     arch.Indirect
+	funOffset    int64
+	ptrSize      int64
+	funcByEntry  map[uint64]*Function
+	itabByAddr   map[uint64]*Itab
+	itabsByIface map[uintptr][]*Itab
+	implementers map[uintptr][]reflect.Type
+	callers      map[*ssa.Function][]*Function
+	typeConv     *binarytypes.Converter
+	cfg          func(fn *Function) (*binarycfg.Graph, error)
+	graphs       map[*Function]*binarycfg.Graph
+	args         map[argKey][]*Itab
+}
+
+// argKey is the argument of a function traced in the callers at the depth.
+type argKey struct {
+	fn    *Function
+	loc   arch.Loc
+	depth int
+}
+
+// maxArgTraceDepth is the maximum number of the callers to trace an itab passed as an argument through.
+const maxArgTraceDepth = 3
+
+// interfaceCallEdges returns the edges of the interface method calls in funcs.
+func (a *analyzer) interfaceCallEdges(funcs []*Function, arc arch.Arch, indirect arch.Indirect) ([]*callEdge, error) {
+	layout, _, err := a.module()
+	if err != nil {
+		return nil, err
+	}
+	itabs, err := a.itabs()
+	if err != nil {
+		return nil, err
+	}
+	impls, err := a.implementations()
+	if err != nil {
+		return nil, err
+	}
+	r := &interfaceCallResolver{
+		arc:          arc,
+		indirect:     indirect,
+		funOffset:    int64(layout.ItabFunOffset()),
+		ptrSize:      int64(layout.PtrSize()),
+		funcByEntry:  make(map[uint64]*Function, len(funcs)),
+		itabByAddr:   make(map[uint64]*Itab, len(itabs)),
+		itabsByIface: map[uintptr][]*Itab{},
+		implementers: map[uintptr][]reflect.Type{},
+		callers:      map[*ssa.Function][]*Function{},
+		typeConv:     a.typeConv,
+		cfg:          a.cfg,
+		graphs:       map[*Function]*binarycfg.Graph{},
+		args:         map[argKey][]*Itab{},
+	}
+	for _, fn := range funcs {
+		r.funcByEntry[fn.SymFunc.Entry] = fn
+		for _, callee := range fn.Callee {
+			r.callers[callee] = append(r.callers[callee], fn)
+		}
+	}
+	for _, itab := range itabs {
+		r.itabByAddr[itab.Addr] = itab
+		r.itabsByIface[itab.Interface.Addr()] = append(r.itabsByIface[itab.Interface.Addr()], itab)
+	}
+	for _, impl := range impls.Interfaces {
+		r.implementers[impl.Interface.Addr()] = impl.Implementers
+	}
+	var edges []*callEdge
+	for _, fn := range funcs {
+		for i := range fn.Inst {
+			load, base, disp, ok := indirect.IndirectCallee(fn.Inst, i)
+			if !ok {
+				continue
+			}
+			index, ok := r.methodIndex(disp)
+			if !ok {
+				continue
+			}
+			for _, c := range r.candidates(fn, load, base, index) {
+				site := &ssa.Call{Call: ssa.CallCommon{Method: c.method}}
+				for _, callee := range c.callees {
+					edges = append(edges, &callEdge{caller: fn, site: site, callee: callee.fn, typ: callee.typ})
+				}
+			}
+		}
+	}
+	return edges, nil
+}
+
+// methodIndex returns the index of the method in the itab if the callee is loaded from the displacement of an itab.
+func (r *interfaceCallResolver) methodIndex(disp int64) (int, bool) {
+	if disp < r.funOffset || (disp-r.funOffset)%r.ptrSize != 0 {
+		return 0, false
+	}
+	return int((disp - r.funOffset) / r.ptrSize), true
+}
+
+// interfaceCall is the callee candidates of an interface method call for an interface type.
+type interfaceCall struct {
+	method  *types.Func
+	callees []*methodCallee
+}
+
+// methodCallee is the method of a concrete type called through an interface.
+// The method of T and *T can share the same code, so it is distinguished by the type.
+type methodCallee struct {
+	typ reflect.Type
+	fn  *Function
+}
+
+// candidates returns the candidates of the call that loads the index-th method from the itab in the base register of fn.Inst[load].
+func (r *interfaceCallResolver) candidates(fn *Function, load int, base string, index int) []*interfaceCall {
+	var calls []*interfaceCall
+	seen := map[uintptr]struct{}{}
+	for _, itab := range r.itabsAt(fn, load, arch.Loc{Reg: base}, 0) {
+		if _, exists := seen[itab.Interface.Addr()]; exists {
+			continue
+		}
+		seen[itab.Interface.Addr()] = struct{}{}
+		if call := r.interfaceCall(itab.Interface, index); call != nil {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// itabsAt returns the itabs that can be in loc before fn.Inst[i] is executed.
+// The value is traced backward along all the paths of the control-flow graph, and the paths that do not end with an itab contribute nothing.
+func (r *interfaceCallResolver) itabsAt(fn *Function, i int, loc arch.Loc, depth int) []*Itab {
+	g := r.graph(fn)
+	if g == nil {
+		return nil
+	}
+	block := g.Block(fn.Inst[i].PC)
+	if block == nil {
+		return nil
+	}
+	type key struct {
+		block int
+		loc   arch.Loc
+	}
+	var (
+		itabs []*Itab
+		seen  = map[key]struct{}{}
+		visit func(b *binarycfg.Block, n int, loc arch.Loc)
+	)
+	// visit traces loc before b.Insts[n].
+	visit = func(b *binarycfg.Block, n int, loc arch.Loc) {
+		for j := n - 1; j >= 0; j-- {
+			prev, addr, ok := r.indirect.TraceBack(b.Insts, j, loc)
+			if !ok {
+				return
+			}
+			if addr != 0 {
+				if itab, exists := r.itabByAddr[addr]; exists {
+					itabs = append(itabs, itab)
+				}
+				return
+			}
+			loc = prev
+		}
+		// the entry can have the predecessor that calls runtime.morestack and jumps back, but it preserves the arguments.
+		if b == g.Entry {
+			itabs = append(itabs, r.argItabs(fn, loc, depth)...)
+			return
+		}
+		for _, pred := range b.Preds {
+			k := key{block: pred.Index, loc: loc}
+			if _, exists := seen[k]; exists {
+				continue
+			}
+			seen[k] = struct{}{}
+			visit(pred, len(pred.Insts), loc)
+		}
+	}
+	n := 0
+	for n < len(block.Insts) && block.Insts[n].PC != fn.Inst[i].PC {
+		n++
+	}
+	visit(block, n, loc)
+	return itabs
+}
+
+// argItabs returns the itabs that the direct callers of fn pass in loc.
+func (r *interfaceCallResolver) argItabs(fn *Function, loc arch.Loc, depth int) []*Itab {
+	if depth >= maxArgTraceDepth {
+		return nil
+	}
+	k := argKey{fn: fn, loc: loc, depth: depth}
+	if itabs, exists := r.args[k]; exists {
+		return itabs
+	}
+	// the recursive calls see no itabs.
+	r.args[k] = nil
+	var itabs []*Itab
+	callerLoc, ok := r.indirect.CallerLoc(loc)
+	if !ok {
+		return nil
+	}
+	for _, caller := range r.callers[fn.SSAFunc] {
+		for i, inst := range caller.Inst {
+			if addr, ok := r.arc.CallTarget(inst); ok && addr == fn.SymFunc.Entry {
+				itabs = append(itabs, r.itabsAt(caller, i, callerLoc, depth+1)...)
+			}
+		}
+	}
+	r.args[k] = itabs
+	return itabs
+}
+
+// graph returns the control-flow graph of fn. It returns nil if the graph cannot be built.
+func (r *interfaceCallResolver) graph(fn *Function) *binarycfg.Graph {
+	if g, exists := r.graphs[fn]; exists {
+		return g
+	}
+	g, err := r.cfg(fn)
+	if err != nil {
+		g = nil
+	}
+	r.graphs[fn] = g
+	return g
+}
+
+// interfaceCall returns the candidates of the call of the index-th method of iface. It returns nil if iface has no such method.
+func (r *interfaceCallResolver) interfaceCall(iface reflect.Type, index int) *interfaceCall {
+	if index >= iface.NumMethod() {
+		return nil
+	}
+	imethod := iface.Method(index)
+	call := &interfaceCall{method: r.interfaceMethodFunc(imethod)}
+	type key struct {
+		typ  uintptr
+		addr uint64
+	}
+	seen := map[key]struct{}{}
+	add := func(typ reflect.Type, addr uint64) {
+		fn, exists := r.funcByEntry[addr]
+		if !exists || fn.SymFunc.Name == unreachableMethod {
+			return
+		}
+		k := key{typ: typ.Addr(), addr: addr}
+		if _, exists := seen[k]; exists {
+			return
+		}
+		seen[k] = struct{}{}
+		call.callees = append(call.callees, &methodCallee{typ: typ, fn: fn})
+	}
+	for _, itab := range r.itabsByIface[iface.Addr()] {
+		if index < len(itab.Funcs) {
+			add(itab.Type, itab.Funcs[index])
+		}
+	}
+	for _, typ := range r.implementers[iface.Addr()] {
+		for _, m := range typ.AllMethods() {
+			if m.Name == imethod.Name && m.PkgPath == imethod.PkgPath && m.Ifn != 0 {
+				add(typ, uint64(m.Ifn))
+			}
+		}
+	}
+	return call
+}
+
+func (r *interfaceCallResolver) interfaceMethodFunc(m reflect.Method) *types.Func {
+	sig := types.NewSignature(nil, nil, nil, false)
+	if m.Type != nil {
+		if s, err := r.typeConv.SignatureFromReflectType(m.Type); err == nil {
+			sig = s
+		}
+	}
+	return types.NewFunc(token.NoPos, r.typeConv.Package(m.PkgPath), m.Name, sig)
+}
+
+// funcLiteralPattern matches the names of func literals ( pkg.fn.func1, pkg.fn.func1.2, pkg.glob..func1 ) and
+// the wrappers of go and defer statements generated since Go 1.22 ( pkg.fn.gowrap1, pkg.fn.deferwrap1 ).
+// The other names that end with a number ( e.g. pkg.init.0 ) are not func literals.
+var funcLiteralPattern = regexp.MustCompile(`\.(func|gowrap|deferwrap)\d+(\.\d+)*$`)
+
+// Callees that receive the funcval of go and defer statements.
+var (
+	newprocFuncs   = []string{"runtime.newproc"}
+	deferprocFuncs = []string{"runtime.deferproc", "runtime.deferprocStack", "runtime.deferprocat"}
+)
+
+// maxFuncValueScan is the maximum number of instructions to scan forward for the call that receives a funcval.
+const maxFuncValueScan = 32
+
+// funcLiteralEdges returns the edges from the functions that create func literals.
+//
+// A function creates a func literal by materializing the code address of the literal in a closure object,
+// or the address of its static funcval ( pkg.fn.func1·f ) if it captures no variables.
+// The kind of the edge is decided from the names generated by the compiler and the first call after the reference:
+//
+//   - go: the wrapper of a go statement ( gowrap ) or the funcval passed to runtime.newproc.
+//   - defer: the wrapper of a defer statement ( deferwrap ), the funcval passed to runtime.deferproc,
+//     or the literal that is called directly by a function with open-coded defers ( it calls runtime.deferreturn ).
+//   - closure: otherwise.
+//
+// Before Go 1.22, deferred closures that capture variables in open-coded defers are reported as closure.
+func (a *analyzer) funcLiteralEdges(funcs []*Function, arc arch.Arch, indirect arch.Indirect) ([]*callEdge, error) {
+	layout, _, err := a.module()
+	if err != nil {
+		return nil, err
+	}
+	ptrSize := layout.PtrSize()
+	bo := a.obj.byteOrder()
+	funcByEntry := make(map[uint64]*Function, len(funcs))
+	funcBySSA := make(map[*ssa.Function]*Function, len(funcs))
+	for _, fn := range funcs {
+		funcByEntry[fn.SymFunc.Entry] = fn
+		funcBySSA[fn.SSAFunc] = fn
+	}
+	// funcByValue returns the function whose code address or funcval is at addr.
+	funcByValue := func(addr uint64) *Function {
+		if fn, exists := funcByEntry[addr]; exists {
+			return fn
+		}
+		b, err := a.obj.readAddr(addr, ptrSize)
+		if err != nil {
+			return nil
+		}
+		if ptrSize == 4 {
+			return funcByEntry[uint64(bo.Uint32(b))]
+		}
+		return funcByEntry[bo.Uint64(b)]
+	}
+	var edges []*callEdge
+	for _, fn := range funcs {
+		var (
+			openCoded    bool
+			directCallee = map[*ssa.Function]struct{}{}
+		)
+		for _, callee := range fn.Callee {
+			directCallee[callee] = struct{}{}
+			if f, exists := funcBySSA[callee]; exists && f.SymFunc.Name == "runtime.deferreturn" {
+				openCoded = true
+			}
+		}
+		for i := range fn.Inst {
+			addr, ok := indirect.AddrRef(fn.Inst, i)
+			if !ok || (fn.SymFunc.Entry <= addr && addr < fn.SymFunc.End) {
+				continue
+			}
+			lit := funcByValue(addr)
+			if lit == nil {
+				continue
+			}
+			next := nextCallee(fn, i, arc, funcByEntry)
+			var site ssa.CallInstruction
+			call := ssa.CallCommon{Value: lit.SSAFunc}
+			name := lit.SymFunc.Name
+			switch {
+			case strings.Contains(name, ".gowrap") || containsString(newprocFuncs, next):
+				site = &ssa.Go{Call: call}
+			case strings.Contains(name, ".deferwrap") || containsString(deferprocFuncs, next):
+				site = &ssa.Defer{Call: call}
+			case !funcLiteralPattern.MatchString(name):
+				// a function value that is not a func literal ( e.g. pkg.fn·f ) is not a closure.
+				continue
+			default:
+				if _, called := directCallee[lit.SSAFunc]; openCoded && called {
+					site = &ssa.Defer{Call: call}
+				} else {
+					site = &ssa.Call{Call: ssa.CallCommon{Value: &ssa.MakeClosure{Fn: lit.SSAFunc}}}
+				}
+			}
+			edges = append(edges, &callEdge{caller: fn, site: site, callee: lit})
+		}
+	}
+	return edges, nil
+}
+
+// nextCallee returns the name of the function that the first direct call after fn.Inst[i] calls, skipping the write barrier.
+func nextCallee(fn *Function, i int, arc arch.Arch, funcByEntry map[uint64]*Function) string {
+	for j := i + 1; j < len(fn.Inst) && j <= i+maxFuncValueScan; j++ {
+		target, ok := arc.CallTarget(fn.Inst[j])
+		if !ok {
+			continue
+		}
+		callee, exists := funcByEntry[target]
+		if !exists {
+			return ""
+		}
+		if strings.HasPrefix(callee.SymFunc.Name, "runtime.gcWriteBarrier") {
+			// the write barrier for storing the funcval into the closure object.
+			continue
+		}
+		return callee.SymFunc.Name
+	}
+	return ""
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
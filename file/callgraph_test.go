@@ -0,0 +1,207 @@
+package file_test
+
+import (
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+func TestCallGraphInterfaceCall(t *testing.T) {
+	for _, name := range []string{"elf", "macho_arm64", "pe"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			f, err := file.Open(filepath.Join("testdata", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			graph, err := f.CallGraph()
+			if err != nil {
+				t.Fatal(err)
+			}
+			edges := map[string]string{}
+			if err := callgraph.GraphVisitEdges(graph, func(edge *callgraph.Edge) error {
+				edges[edge.Caller.Func.Name()+" -> "+edge.Callee.Func.Name()] = edge.Description()
+				return nil
+			}); err != nil {
+				t.Fatal(err)
+			}
+			for edge, expected := range map[string]string{
+				"main -> f": "static function call",
+				// f calls (*T).F through the itab of main.Iface created in main.
+				"f -> F": "dynamic method call",
+			} {
+				if got := edges[edge]; got != expected {
+					t.Fatalf("expected %q for %s but got %q", expected, edge, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCallGraphFuncLiteral(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		edges   map[string]string
+	}{
+		{
+			version: "go1.21.13",
+			edges: map[string]string{
+				"main.main -> main.main.func3": "concurrent static function call",
+				"main.main -> main.main.func1": "deferred static function call",
+				"main.main -> main.main.func2": "static function closure call",
+			},
+		},
+		// the compiler generates wrappers of go and defer statements.
+		{
+			version: "go1.27.1",
+			edges: map[string]string{
+				"main.main -> main.main.gowrap1":        "concurrent static function call",
+				"main.main -> main.main.func1":          "deferred static function call",
+				"main.main -> main.main.func2":          "static function closure call",
+				"main.worker -> main.worker.deferwrap1": "deferred static function call",
+			},
+		},
+	} {
+		test := test
+		t.Run(test.version, func(t *testing.T) {
+			f, err := file.Open(filepath.Join("testdata", "closure", test.version))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			edges := callGraphEdges(t, f)
+			for edge, expected := range test.edges {
+				if got := edges[edge]; got != expected {
+					t.Fatalf("expected %q for %s but got %q", expected, edge, got)
+				}
+			}
+		})
+	}
+}
+
+func TestFuncLiteralName(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"main.main.func1":        true,
+		"main.main.func1.2":      true,
+		"main.glob..func1":       true,
+		"main.main.gowrap1":      true,
+		"main.worker.deferwrap1": true,
+		"main.main":              false,
+		"main.init.0":            false,
+		"main.T.1":               false,
+		"main.funcs":             false,
+	} {
+		if got := file.IsFuncLiteral(name); got != expected {
+			t.Fatalf("expected %v for %s but got %v", expected, name, got)
+		}
+	}
+}
+
+func TestCallGraphAlgorithm(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "elf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, test := range []struct {
+		algorithm file.CallGraphAlgorithm
+		edges     map[string]bool
+	}{
+		{
+			algorithm: file.CHA,
+			edges: map[string]bool{
+				"main.f -> main.(*T).F": true,
+				// the only interface method call of main.f is v.F, and the itab of io.Writer is passed to fmt.Fprintln.
+				"main.f -> os.(*File).Write":          false,
+				"main.f -> internal/poll.(*FD).Write": false,
+				"fmt.Fprintln -> os.(*File).Write":    true,
+				// *poll.FD implements io.Writer.
+				"fmt.Fprintln -> internal/poll.(*FD).Write": true,
+			},
+		},
+		{
+			algorithm: file.RTA,
+			edges: map[string]bool{
+				"main.f -> main.(*T).F":            true,
+				"main.f -> os.(*File).Write":       false,
+				"fmt.Fprintln -> os.(*File).Write": true,
+				// *poll.FD implements io.Writer, but it is never converted to the interface type.
+				"fmt.Fprintln -> internal/poll.(*FD).Write": false,
+			},
+		},
+	} {
+		test := test
+		t.Run(test.algorithm.String(), func(t *testing.T) {
+			edges := callGraphEdges(t, f, file.WithAlgorithm(test.algorithm))
+			for edge, expected := range test.edges {
+				if got := edges[edge] == "dynamic method call"; got != expected {
+					t.Fatalf("expected the existence of %s to be %v but got %v", edge, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCallGraphSite(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "closure", "go1.27.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	graph, err := f.CallGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]bool{}
+	if err := callgraph.GraphVisitEdges(graph, func(edge *callgraph.Edge) error {
+		// the synthesized sites carry only the call and belong to no block.
+		if edge.Site.Common() == nil || edge.Site.Block() != nil || edge.Pos() != token.NoPos {
+			t.Fatalf("unexpected site of %s", edge)
+		}
+		if !strings.Contains(edge.String(), edge.Caller.Func.Name()) || edge.Description() == "" {
+			t.Fatalf("failed to describe %s", edge)
+		}
+		kinds[fmt.Sprintf("%T", edge.Site)] = true
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, kind := range []string{"*ssa.Call", "*ssa.Go", "*ssa.Defer"} {
+		if !kinds[kind] {
+			t.Fatalf("failed to find the site of %s", kind)
+		}
+	}
+}
+
+// callGraphEdges returns the description of each edge of the call graph keyed by the qualified names of the caller and the callee.
+func callGraphEdges(t *testing.T, f file.File, opts ...file.CallGraphOption) map[string]string {
+	t.Helper()
+	graph, err := f.CallGraph(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs, err := f.Funcs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the names of ssa.Function are not qualified, so func literals of runtime.main have the same name.
+	names := map[*ssa.Function]string{}
+	for _, fn := range funcs {
+		names[fn.SSAFunc] = fn.SymFunc.Name
+	}
+	edges := map[string]string{}
+	if err := callgraph.GraphVisitEdges(graph, func(edge *callgraph.Edge) error {
+		edges[names[edge.Caller.Func]+" -> "+names[edge.Callee.Func]] = edge.Description()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return edges
+}
@@ -0,0 +1,39 @@
+package file
+
+import (
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/arch"
+)
+
+// cfg builds the control-flow graph of fn.
+// The jump tables of the switch statements are read from the binary, and the calls of the functions that never return end the blocks.
+func (a *analyzer) cfg(fn *Function) (*binarycfg.Graph, error) {
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	arc, err := arch.Lookup(a.obj.arch())
+	if err != nil {
+		return nil, err
+	}
+	noReturn := a.noReturnFuncs(funcs)
+	return binarycfg.Build(arc, fn.Inst, fn.SymFunc.End,
+		binarycfg.WithMemory(a.obj.readAddr),
+		binarycfg.WithNoReturn(func(addr uint64) bool {
+			return noReturn[addr]
+		}),
+	)
+}
+
+// noReturnFuncs returns the entries of the functions in funcs that never return. They are collected once and shared by the following calls.
+func (a *analyzer) noReturnFuncs(funcs []*Function) map[uint64]bool {
+	a.noReturnOnce.Do(func() {
+		a.noReturn = map[uint64]bool{}
+		for _, f := range funcs {
+			if binarycfg.NoReturnFunc(f.SymFunc.Name) {
+				a.noReturn[f.SymFunc.Entry] = true
+			}
+		}
+	})
+	return a.noReturn
+}
@@ -0,0 +1,180 @@
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/file"
+)
+
+func TestCFG(t *testing.T) {
+	build := func(t *testing.T, path, name string) *binarycfg.Graph {
+		t.Helper()
+		f, err := file.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		funcs, err := f.Funcs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fn := range funcs {
+			if fn.SymFunc.Name == name {
+				g, err := f.CFG(fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return g
+			}
+		}
+		t.Fatalf("failed to find %s", name)
+		return nil
+	}
+	checkEdges := func(t *testing.T, g *binarycfg.Graph) {
+		t.Helper()
+		if g.Entry != g.Blocks[0] {
+			t.Fatal("the entry must be the first block")
+		}
+		for i, b := range g.Blocks {
+			if b.Index != i || len(b.Insts) == 0 {
+				t.Fatalf("unexpected block %d:\n%s", i, g)
+			}
+			for _, succ := range b.Succs {
+				if !containsBlock(succ.Preds, b) {
+					t.Fatalf("block %d is not a predecessor of its successor %d:\n%s", b.Index, succ.Index, g)
+				}
+			}
+		}
+	}
+	t.Run("padding", func(t *testing.T) {
+		g := build(t, filepath.Join("testdata", "elf"), "main.(*T).F")
+		checkEdges(t, g)
+		if len(g.Padding) == 0 {
+			t.Fatalf("failed to find the padding:\n%s", g)
+		}
+		for _, inst := range g.Padding {
+			if inst.Op != "INT" {
+				t.Fatalf("unexpected padding %s", inst.Op)
+			}
+			if g.Block(inst.PC) != nil {
+				t.Fatalf("padding at %#x must not be in a block", inst.PC)
+			}
+		}
+		var returns bool
+		for _, b := range g.Blocks {
+			returns = returns || b.Kind == binarycfg.Return
+		}
+		if !returns {
+			t.Fatalf("failed to find the return:\n%s", g)
+		}
+	})
+	t.Run("dominators", func(t *testing.T) {
+		g := build(t, filepath.Join("testdata", "elf"), "strconv.formatBits")
+		dom, pdom := g.Dominators(), g.PostDominators()
+		if roots := dom.Roots(); len(roots) != 1 || roots[0] != g.Entry {
+			t.Fatalf("the entry must be the root of the dominator tree")
+		}
+		for _, b := range g.Blocks {
+			if idom := dom.Idom(b); idom != nil && !dom.Dominates(g.Entry, b) {
+				t.Fatalf("the entry does not dominate block %d", b.Index)
+			}
+			if len(b.Succs) == 0 && dom.Dominates(g.Entry, b) && !containsBlock(pdom.Roots(), b) {
+				t.Fatalf("the exit %d must be a root of the post-dominator tree", b.Index)
+			}
+			for _, child := range dom.Children(b) {
+				if dom.Idom(child) != b {
+					t.Fatalf("block %d is not the immediate dominator of its child %d", b.Index, child.Index)
+				}
+			}
+		}
+		loops := g.Loops()
+		if len(loops) < 2 {
+			t.Fatalf("failed to find the loops of the digits:\n%s", g)
+		}
+		for _, loop := range loops {
+			for _, b := range loop.Blocks {
+				if !dom.Dominates(loop.Header, b) {
+					t.Fatalf("the header %d does not dominate block %d of the loop", loop.Header.Index, b.Index)
+				}
+			}
+			for _, latch := range loop.Latches {
+				if !containsBlock(latch.Succs, loop.Header) {
+					t.Fatalf("the latch %d does not jump to the header %d", latch.Index, loop.Header.Index)
+				}
+			}
+		}
+		if g.Complexity() <= len(loops) {
+			t.Fatalf("unexpected complexity %d", g.Complexity())
+		}
+	})
+	t.Run("arm64", func(t *testing.T) {
+		g := build(t, filepath.Join("testdata", "macho_arm64"), "strconv.formatBits")
+		checkEdges(t, g)
+		// NOP follows the call of runtime.gopanic.
+		if len(g.Padding) != 1 || g.Padding[0].Op != "NOP" || g.Block(g.Padding[0].PC) != nil {
+			t.Fatalf("unexpected padding %v:\n%s", g.Padding, g)
+		}
+		kinds := map[binarycfg.Kind]bool{}
+		for _, b := range g.Blocks {
+			kinds[b.Kind] = true
+		}
+		for _, kind := range []binarycfg.Kind{binarycfg.If, binarycfg.Jump, binarycfg.Return, binarycfg.Exit} {
+			if !kinds[kind] {
+				t.Fatalf("failed to find the %s block:\n%s", kind, g)
+			}
+		}
+		if loops := g.Loops(); len(loops) < 2 {
+			t.Fatalf("failed to find the loops of the digits:\n%s", g)
+		}
+	})
+	t.Run("jump table", func(t *testing.T) {
+		g := build(t, filepath.Join("testdata", "goversion", "go1.27.1"), "runtime.printanycustomtype")
+		checkEdges(t, g)
+		var table *binarycfg.Block
+		for _, b := range g.Blocks {
+			if b.Kind == binarycfg.JumpTable {
+				table = b
+			}
+		}
+		if table == nil {
+			t.Fatalf("failed to resolve the jump table:\n%s", g)
+		}
+		// the kind of the type is bounded by CMPQ CX, $0x17.
+		if len(table.Table) != 0x18 {
+			t.Fatalf("expected 24 cases but got %d", len(table.Table))
+		}
+		for _, dst := range table.Table {
+			if !containsBlock(table.Succs, dst) {
+				t.Fatalf("the case %#x is not a successor", dst.Addr)
+			}
+		}
+	})
+}
+
+func containsBlock(blocks []*binarycfg.Block, target *binarycfg.Block) bool {
+	for _, b := range blocks {
+		if b == target {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNoReturnFunc(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"runtime.gopanic":          true,
+		"runtime.panicIndex":       true,
+		"runtime.goPanicSliceAlen": true,
+		"runtime.panicdivide":      true,
+		// panicCheck1 and panicCheck2 panic only if the check fails in the runtime.
+		"runtime.panicCheck1": false,
+		"runtime.panicCheck2": false,
+		"main.panicky":        false,
+	} {
+		if got := binarycfg.NoReturnFunc(name); got != expected {
+			t.Fatalf("expected %v for %s but got %v", expected, name, got)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package file
+
+import (
+	"debug/dwarf"
+	"debug/gosym"
+	"go/token"
+	"go/types"
+	"strings"
+
+	internalreflect "github.com/goccy/binarian/internal/reflect"
+	"github.com/goccy/binarian/reflect"
+)
+
+// attrGoRuntimeType is DW_AT_go_runtime_type, the offset of the runtime type of a DWARF type from the head of the type data.
+const attrGoRuntimeType dwarf.Attr = 0x2904
+
+// funcSignatures returns the signatures of the functions without receivers keyed by entry.
+// They are built once and shared by the following calls.
+func (a *analyzer) funcSignatures() map[uint64]*types.Signature {
+	a.signaturesOnce.Do(func() {
+		a.signatures = a.loadFuncSignatures()
+	})
+	return a.signatures
+}
+
+// loadFuncSignatures builds the signatures of the functions without receivers from the DWARF.
+//
+// The binary has the type metadata of the methods only, so the parameters and the results ( DW_TAG_formal_parameter ) of
+// the functions in the DWARF are typed by the runtime types that their DWARF types refer to ( DW_AT_go_runtime_type ),
+// and the runtime types are converted by the shared Converter.
+// A signature is built only if all parameters and results have runtime types and they account for the args size in the funcdata.
+// The compile units built by Go 1.17 with the register ABI are skipped, because the compiler omits the results in registers from them.
+// The instantiations of generic functions are not included, because they have the dictionary parameter.
+// DWARF does not record the variadic parameter, so it is the slice parameter.
+//
+// It returns an empty map if the binary has no DWARF ( e.g. built with -ldflags=-w ) or it cannot be decoded.
+func (a *analyzer) loadFuncSignatures() map[uint64]*types.Signature {
+	sigs := map[uint64]*types.Signature{}
+	data, err := a.obj.dwarf()
+	if err != nil {
+		return sigs
+	}
+	_, mod, err := a.module()
+	if err != nil {
+		return sigs
+	}
+	infos, err := a.funcInfos()
+	if err != nil {
+		return sigs
+	}
+	pclntab, err := a.obj.section(gopclntabSection)
+	if err != nil || !isPclntabHeader(pclntab.data, a.obj.byteOrder()) {
+		return sigs
+	}
+	d := &dwarfSignatureDecoder{
+		data:    data,
+		mod:     mod,
+		a:       a,
+		ptrSize: int(pclntab.data[7]),
+		entries: map[dwarf.Offset]*dwarf.Entry{},
+		types:   map[dwarf.Offset]reflect.Type{},
+	}
+	r := data.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil || e == nil {
+			return sigs
+		}
+		switch {
+		case e.Tag == dwarf.TagCompileUnit && omitsRegisterResults(e):
+			r.SkipChildren()
+		case e.Tag == dwarf.TagSubprogram:
+			params, err := d.formalParameters(r, e)
+			if err != nil {
+				return sigs
+			}
+			entry, ok := e.Val(dwarf.AttrLowpc).(uint64)
+			if !ok {
+				// the abstract function of the inlined calls.
+				continue
+			}
+			info, exists := infos[entry]
+			if !exists || info.args < 0 {
+				continue
+			}
+			if sig := d.signature(e, params, int(info.args)); sig != nil {
+				sigs[entry] = sig
+			}
+		case e.Tag != dwarf.TagCompileUnit && e.Children:
+			r.SkipChildren()
+		}
+	}
+}
+
+type dwarfSignatureDecoder struct {
+	data    *dwarf.Data
+	mod     *internalreflect.Module
+	a       *analyzer
+	ptrSize int
+	// entries caches the entries referred to by DW_AT_abstract_origin and DW_AT_type.
+	entries map[dwarf.Offset]*dwarf.Entry
+	// types caches the runtime types of the DWARF types. It is nil if the DWARF type has no runtime type.
+	types map[dwarf.Offset]reflect.Type
+}
+
+// formalParameters reads the children of the subprogram e from r, and returns its formal parameters.
+func (d *dwarfSignatureDecoder) formalParameters(r *dwarf.Reader, e *dwarf.Entry) ([]*dwarf.Entry, error) {
+	if !e.Children {
+		return nil, nil
+	}
+	var params []*dwarf.Entry
+	for {
+		child, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if child == nil || child.Tag == 0 {
+			return params, nil
+		}
+		if child.Tag == dwarf.TagFormalParameter {
+			params = append(params, child)
+		}
+		if child.Children {
+			// the lexical blocks and the inlined calls.
+			r.SkipChildren()
+		}
+	}
+}
+
+// omitsRegisterResults reports whether the compile unit cu is built by Go 1.17 with the register ABI ( e.g. "Go cmd/compile go1.17.13; regabi" ).
+func omitsRegisterResults(cu *dwarf.Entry) bool {
+	producer, _ := cu.Val(dwarf.AttrProducer).(string)
+	version, flags, _ := strings.Cut(strings.TrimPrefix(producer, "Go cmd/compile "), ";")
+	return (version == "go1.17" || strings.HasPrefix(version, "go1.17.")) && strings.Contains(flags, "regabi")
+}
+
+// signature returns the signature of the subprogram e, or nil if it cannot be built from params.
+func (d *dwarfSignatureDecoder) signature(e *dwarf.Entry, params []*dwarf.Entry, argsSize int) *types.Signature {
+	name, _ := d.val(e, dwarf.AttrName).(string)
+	sym := &gosym.Sym{Name: name}
+	if name == "" || sym.ReceiverName() != "" || strings.Contains(name, "[") {
+		return nil
+	}
+	pkg := d.a.typeConv.Package(sym.PackageName())
+	var (
+		ins, outs  []*types.Var
+		size       int
+		paramsSize int
+	)
+	// the parameters and the results are laid out on the stack by ABI0 to compute the args size.
+	layout := func(typ reflect.Type) {
+		size = alignUp(size, typ.Align()) + int(typ.Size())
+	}
+	for _, param := range params {
+		typeOff, ok := d.val(param, dwarf.AttrType).(dwarf.Offset)
+		if !ok {
+			return nil
+		}
+		typ := d.runtimeType(typeOff)
+		if typ == nil {
+			return nil
+		}
+		paramName, _ := d.val(param, dwarf.AttrName).(string)
+		if strings.HasPrefix(paramName, "~") {
+			// the compiler names the unnamed parameters and results ( e.g. ~r0 ).
+			paramName = ""
+		}
+		v := types.NewVar(token.NoPos, pkg, paramName, d.a.typeConv.TypeFromReflectType(typ))
+		if isResult, _ := d.val(param, dwarf.AttrVarParam).(bool); isResult {
+			if len(outs) == 0 {
+				paramsSize = size
+				size = alignUp(size, d.ptrSize)
+			}
+			outs = append(outs, v)
+		} else {
+			if len(outs) != 0 {
+				return nil
+			}
+			ins = append(ins, v)
+		}
+		layout(typ)
+	}
+	if len(outs) == 0 {
+		paramsSize = size
+	}
+	// the args size of the register ABI does not include the results.
+	if alignUp(size, d.ptrSize) != argsSize && alignUp(paramsSize, d.ptrSize) != argsSize {
+		return nil
+	}
+	return types.NewSignature(nil, types.NewTuple(ins...), types.NewTuple(outs...), false)
+}
+
+// val returns the attribute of e. The concrete entry of an inlinable function refers to the abstract one for its attributes.
+func (d *dwarfSignatureDecoder) val(e *dwarf.Entry, attr dwarf.Attr) interface{} {
+	if v := e.Val(attr); v != nil {
+		return v
+	}
+	origin, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+	if !ok {
+		return nil
+	}
+	if originEntry := d.entry(origin); originEntry != nil {
+		return originEntry.Val(attr)
+	}
+	return nil
+}
+
+func (d *dwarfSignatureDecoder) entry(off dwarf.Offset) *dwarf.Entry {
+	if e, exists := d.entries[off]; exists {
+		return e
+	}
+	r := d.data.Reader()
+	r.Seek(off)
+	e, err := r.Next()
+	if err != nil {
+		e = nil
+	}
+	d.entries[off] = e
+	return e
+}
+
+// runtimeType returns the runtime type of the DWARF type at off. It returns nil if the DWARF type has no runtime type.
+// A named type is the typedef that refers to the typedef with the runtime type.
+func (d *dwarfSignatureDecoder) runtimeType(off dwarf.Offset) reflect.Type {
+	if typ, exists := d.types[off]; exists {
+		return typ
+	}
+	var typ reflect.Type
+	if e := d.entry(off); e != nil {
+		if typeOff, ok := e.Val(attrGoRuntimeType).(uint64); ok {
+			if t, err := d.mod.TypeByOffset(int32(typeOff)); err == nil {
+				typ = t
+			}
+		} else if next, ok := e.Val(dwarf.AttrType).(dwarf.Offset); ok && e.Tag == dwarf.TagTypedef && d.sameName(e, next) {
+			d.types[off] = nil
+			typ = d.runtimeType(next)
+		}
+	}
+	d.types[off] = typ
+	return typ
+}
+
+// sameName reports whether the entry at off has the name of e. A typedef that refers to another type is not followed.
+func (d *dwarfSignatureDecoder) sameName(e *dwarf.Entry, off dwarf.Offset) bool {
+	next := d.entry(off)
+	return next != nil && next.Val(dwarf.AttrName) == e.Val(dwarf.AttrName)
+}
+
+func alignUp(n, align int) int {
+	if align <= 1 {
+		return n
+	}
+	return (n + align - 1) &^ (align - 1)
+}
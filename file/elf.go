@@ -0,0 +1,241 @@
+package file
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"unicode"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/reflect"
+	binaryssa "github.com/goccy/binarian/ssa"
+	"golang.org/x/tools/go/callgraph"
+)
+
+type ELFFile struct {
+	File     *elf.File
+	rawFile  io.ReaderAt
+	closer   io.Closer
+	analyzer *analyzer
+}
+
+func NewELFFile(f *os.File) (*ELFFile, error) {
+	return newELFFile(f)
+}
+
+func newELFFile(r io.ReaderAt) (*ELFFile, error) {
+	bin, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	file := &ELFFile{
+		File:    bin,
+		rawFile: r,
+	}
+	file.analyzer = newAnalyzer(file, r)
+	return file, nil
+}
+
+// elfSectionNames is the section names for each kind in order of preference.
+// Since Go 1.27, type data and runtime.moduledata have dedicated sections.
+var elfSectionNames = map[sectionKind][]string{
+	textSection:       {".text"},
+	rodataSection:     {".go.type", ".rodata"},
+	typelinkSection:   {".typelink"},
+	gosymtabSection:   {".gosymtab"},
+	gopclntabSection:  {".gopclntab"},
+	moduledataSection: {".go.module", ".noptrdata"},
+	itablinkSection:   {".itablink"},
+}
+
+func (f *ELFFile) section(kind sectionKind) (*section, error) {
+	names := elfSectionNames[kind]
+	var sect *elf.Section
+	for _, name := range names {
+		if sect = f.File.Section(name); sect != nil {
+			break
+		}
+	}
+	if sect == nil {
+		if kind == gosymtabSection {
+			// .gosymtab is empty and optional since Go 1.3.
+			return &section{}, nil
+		}
+		return nil, fmt.Errorf("failed to find %s section", names[len(names)-1])
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return nil, err
+	}
+	return &section{addr: sect.Addr, data: data}, nil
+}
+
+func (f *ELFFile) readAddr(addr uint64, size int) ([]byte, error) {
+	for _, sect := range f.File.Sections {
+		if sect.Type != elf.SHT_PROGBITS || addr < sect.Addr || sect.Addr+sect.Size < addr+uint64(size) {
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := sect.ReadAt(buf, int64(addr-sect.Addr)); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("failed to find section at %#x", addr)
+}
+
+var elfArches = map[elf.Machine]string{
+	elf.EM_386:       "386",
+	elf.EM_X86_64:    "amd64",
+	elf.EM_ARM:       "arm",
+	elf.EM_AARCH64:   "arm64",
+	elf.EM_LOONGARCH: "loong64",
+	elf.EM_MIPS:      "mips",
+	elf.EM_PPC64:     "ppc64",
+	elf.EM_RISCV:     "riscv64",
+	elf.EM_S390:      "s390x",
+}
+
+func (f *ELFFile) arch() string {
+	if arch, exists := elfArches[f.File.Machine]; exists {
+		switch arch {
+		case "ppc64":
+			if f.File.ByteOrder == binary.LittleEndian {
+				return "ppc64le"
+			}
+		case "mips":
+			if f.File.Class == elf.ELFCLASS64 {
+				arch = "mips64"
+			}
+			if f.File.ByteOrder == binary.LittleEndian {
+				arch += "le"
+			}
+		}
+		return arch
+	}
+	return f.File.Machine.String()
+}
+
+func (f *ELFFile) byteOrder() binary.ByteOrder {
+	return f.File.ByteOrder
+}
+
+func (f *ELFFile) dwarf() (*dwarf.Data, error) {
+	return f.File.DWARF()
+}
+
+func (f *ELFFile) symbols() ([]Sym, error) {
+	elfSyms, err := f.File.Symbols()
+	if err != nil {
+		if err == elf.ErrNoSymbols {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var syms []Sym
+	for _, s := range elfSyms {
+		sym := Sym{Name: s.Name, Addr: s.Value, Size: int64(s.Size), Code: '?'}
+		switch s.Section {
+		case elf.SHN_UNDEF:
+			sym.Code = 'U'
+		case elf.SHN_COMMON:
+			sym.Code = 'B'
+		default:
+			i := int(s.Section)
+			if i < 0 || i >= len(f.File.Sections) {
+				break
+			}
+			sect := f.File.Sections[i]
+			switch sect.Flags & (elf.SHF_WRITE | elf.SHF_ALLOC | elf.SHF_EXECINSTR) {
+			case elf.SHF_ALLOC | elf.SHF_EXECINSTR:
+				sym.Code = 'T'
+			case elf.SHF_ALLOC:
+				sym.Code = 'R'
+			case elf.SHF_ALLOC | elf.SHF_WRITE:
+				sym.Code = 'D'
+			}
+		}
+		if elf.ST_BIND(s.Info) == elf.STB_LOCAL {
+			sym.Code = unicode.ToLower(sym.Code)
+		}
+		syms = append(syms, sym)
+	}
+	sort.Sort(byAddr(syms))
+	return syms, nil
+}
+
+// Close closes the underlying file if it was opened by Open.
+func (f *ELFFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+func (f *ELFFile) Symbols() ([]Sym, error) {
+	return f.symbols()
+}
+
+func (f *ELFFile) CallGraph(opts ...CallGraphOption) (*callgraph.Graph, error) {
+	return f.analyzer.callGraph(opts...)
+}
+
+func (f *ELFFile) Funcs() ([]*Function, error) {
+	return f.analyzer.funcs()
+}
+
+func (f *ELFFile) Types() ([]reflect.Type, error) {
+	return f.analyzer.types()
+}
+
+// Implementations returns the interface implementation matrix of the binary.
+func (f *ELFFile) Implementations() (*Implementations, error) {
+	return f.analyzer.implementations()
+}
+
+// Methods returns all methods of typ including unexported ones with the functions that implement them.
+func (f *ELFFile) Methods(typ reflect.Type) ([]*Method, error) {
+	return f.analyzer.methods(typ)
+}
+
+// Packages returns the packages of the types and the functions in the binary keyed by import path.
+func (f *ELFFile) Packages() (map[string]*types.Package, error) {
+	return f.analyzer.packages()
+}
+
+// Lift lifts the machine code of fn into SSA form.
+func (f *ELFFile) Lift(fn *Function) (*binaryssa.Function, error) {
+	return f.analyzer.lift(fn)
+}
+
+// CFG builds the control-flow graph of fn.
+// The architectures other than amd64, 386 and arm64 are not supported.
+func (f *ELFFile) CFG(fn *Function) (*binarycfg.Graph, error) {
+	return f.analyzer.cfg(fn)
+}
+
+// Metrics returns the complexity metrics of the functions in the binary grouped by package.
+func (f *ELFFile) Metrics() (*Metrics, error) {
+	return f.analyzer.metrics()
+}
+
+// Frames returns the source positions of the instructions of fn.
+func (f *ELFFile) Frames(fn *Function) ([][]Frame, error) {
+	return f.analyzer.frames(fn)
+}
+
+// LineToPCRanges returns the ranges of the instructions generated from the line of the file.
+func (f *ELFFile) LineToPCRanges(file string, line int) ([]*PCRange, error) {
+	return f.analyzer.lineToPCRanges(file, line)
+}
+
+// Itabs returns the itabs in the binary.
+func (f *ELFFile) Itabs() ([]*Itab, error) {
+	return f.analyzer.itabs()
+}
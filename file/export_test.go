@@ -0,0 +1,6 @@
+package file
+
+// IsFuncLiteral reports whether name is the name of a func literal.
+func IsFuncLiteral(name string) bool {
+	return funcLiteralPattern.MatchString(name)
+}
@@ -0,0 +1,461 @@
+package file
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"debug/elf"
+	"debug/gosym"
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/arch"
+	"github.com/goccy/binarian/internal/goversion"
+	internalreflect "github.com/goccy/binarian/internal/reflect"
+	"github.com/goccy/binarian/reflect"
+	binaryssa "github.com/goccy/binarian/ssa"
+	binarytypes "github.com/goccy/binarian/types"
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/ssa"
+)
+
+// File is a Go binary independent of its container format.
+type File interface {
+	Types() ([]reflect.Type, error)
+	Implementations() (*Implementations, error)
+	Itabs() ([]*Itab, error)
+	Methods(typ reflect.Type) ([]*Method, error)
+	Funcs() ([]*Function, error)
+	CallGraph(opts ...CallGraphOption) (*callgraph.Graph, error)
+	Packages() (map[string]*types.Package, error)
+	Lift(fn *Function) (*binaryssa.Function, error)
+	CFG(fn *Function) (*binarycfg.Graph, error)
+	Metrics() (*Metrics, error)
+	Frames(fn *Function) ([][]Frame, error)
+	LineToPCRanges(file string, line int) ([]*PCRange, error)
+	Symbols() ([]Sym, error)
+	Close() error
+}
+
+var (
+	_ File = (*MachOFile)(nil)
+	_ File = (*ELFFile)(nil)
+	_ File = (*PEFile)(nil)
+)
+
+// Open opens the named file and returns the File for its container format.
+// If the file is a universal Mach-O binary, the slice for the host architecture ( or the first slice ) is returned.
+// Use NewFatMachOFile to access all slices.
+// The returned File must be closed by Close.
+func Open(path string) (File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := newFile(f, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return file, nil
+}
+
+// NewFile detects the container format of r from its magic number and returns the File for it.
+// Universal Mach-O binaries are handled in the same way as Open.
+func NewFile(r io.ReaderAt) (File, error) {
+	return newFile(r, nil)
+}
+
+func newFile(r io.ReaderAt, closer io.Closer) (File, error) {
+	var magic [4]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, fmt.Errorf("failed to read magic number: %w", err)
+	}
+	switch {
+	case bytes.Equal(magic[:], []byte(elf.ELFMAG)):
+		f, err := newELFFile(r)
+		if err != nil {
+			return nil, err
+		}
+		f.closer = closer
+		return f, nil
+	case isMachOMagic(magic):
+		f, err := newMachOFile(r)
+		if err != nil {
+			return nil, err
+		}
+		f.closer = closer
+		return f, nil
+	case binary.BigEndian.Uint32(magic[:]) == macho.MagicFat:
+		f, err := newFatMachOFile(r)
+		if err != nil {
+			return nil, err
+		}
+		slice := f.hostSlice()
+		slice.closer = closer
+		return slice, nil
+	case magic[0] == 'M' && magic[1] == 'Z':
+		f, err := newPEFile(r)
+		if err != nil {
+			return nil, err
+		}
+		f.closer = closer
+		return f, nil
+	}
+	return nil, fmt.Errorf("unsupported file format. magic number is %x", magic)
+}
+
+func isMachOMagic(magic [4]byte) bool {
+	for _, bo := range []binary.ByteOrder{binary.BigEndian, binary.LittleEndian} {
+		switch bo.Uint32(magic[:]) {
+		case macho.Magic32, macho.Magic64:
+			return true
+		}
+	}
+	return false
+}
+
+type Function struct {
+	SymFunc *gosym.Func
+	SSAFunc *ssa.Function
+	Inst    []*Inst
+	Source  []string
+	Callee  []*ssa.Function
+}
+
+// Inst is a decoded machine instruction independent of the architecture.
+// Inst.Raw holds the instruction decoded by golang.org/x/arch ( e.g. x86asm.Inst for amd64 ).
+type Inst = arch.Inst
+
+type Sym struct {
+	Name string
+	Addr uint64
+	Size int64
+	Code rune
+	Type string
+}
+
+type uint64s []uint64
+
+func (x uint64s) Len() int           { return len(x) }
+func (x uint64s) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (x uint64s) Less(i, j int) bool { return x[i] < x[j] }
+
+type byAddr []Sym
+
+func (x byAddr) Less(i, j int) bool { return x[i].Addr < x[j].Addr }
+func (x byAddr) Len() int           { return len(x) }
+func (x byAddr) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+
+type sectionKind int
+
+const (
+	textSection sectionKind = iota
+	rodataSection
+	typelinkSection
+	gosymtabSection
+	gopclntabSection
+	moduledataSection
+	itablinkSection
+)
+
+// section is a loaded section of the binary. addr is the virtual address of data[0].
+type section struct {
+	addr uint64
+	data []byte
+}
+
+// object is implemented by each container format ( Mach-O, ELF ... ).
+// analyzer uses it to access the sections that Go runtime tables live in.
+type object interface {
+	// arch returns the architecture name in GOARCH style.
+	arch() string
+	symbols() ([]Sym, error)
+	section(kind sectionKind) (*section, error)
+	// readAddr reads size bytes at the virtual address addr.
+	readAddr(addr uint64, size int) ([]byte, error)
+	byteOrder() binary.ByteOrder
+	// dwarf returns the debug information. It returns an error if the binary has none.
+	dwarf() (*dwarf.Data, error)
+}
+
+// analyzer implements the format-independent part of Go binary analysis.
+type analyzer struct {
+	obj         object
+	raw         io.ReaderAt
+	allSyms     []Sym
+	allTypes    []reflect.Type
+	funcMap     map[uintptr]*gosym.Func
+	loadOnce    sync.Once
+	funcsOnce   sync.Once
+	allFuncs    []*Function
+	funcsErr    error
+	versionOnce sync.Once
+	version     goversion.Version
+	versionErr  error
+	// typeConv is shared to convert the types of the binary to go/types.
+	typeConv     *binarytypes.Converter
+	packagesOnce sync.Once
+	pkgs         map[string]*types.Package
+	pkgsErr      error
+	implsOnce    sync.Once
+	impls        *Implementations
+	implsErr     error
+	// signatures is the signatures of the functions without receivers built from the DWARF.
+	signaturesOnce sync.Once
+	signatures     map[uint64]*types.Signature
+	// noReturn is the entries of the functions that never return.
+	noReturnOnce sync.Once
+	noReturn     map[uint64]bool
+	// frameDec decodes the source positions of the instructions on demand.
+	frameDecOnce sync.Once
+	frameDec     *frameDecoder
+	frameDecErr  error
+}
+
+func newAnalyzer(obj object, raw io.ReaderAt) *analyzer {
+	return &analyzer{obj: obj, raw: raw, typeConv: binarytypes.NewConverter()}
+}
+
+// goVersion returns the version of the Go toolchain that built the binary.
+func (a *analyzer) goVersion() (goversion.Version, error) {
+	a.versionOnce.Do(func() {
+		a.version, a.versionErr = detectGoVersion(a.raw)
+	})
+	return a.version, a.versionErr
+}
+
+func (a *analyzer) moduledata() (*moduledata, error) {
+	version, err := a.goVersion()
+	if err != nil {
+		return nil, err
+	}
+	pclntab, err := a.obj.section(gopclntabSection)
+	if err != nil {
+		return nil, err
+	}
+	sect, err := a.obj.section(moduledataSection)
+	if err != nil {
+		return nil, err
+	}
+	return findModuledata(sect.data, sect.addr, pclntab.data, pclntab.addr, a.obj.byteOrder(), version)
+}
+
+func (a *analyzer) load() error {
+	syms, err := a.obj.symbols()
+	if err != nil {
+		return err
+	}
+	a.allSyms = syms
+	funcMap := map[uintptr]*gosym.Func{}
+	symtab, err := a.gosymTable()
+	if err != nil {
+		return err
+	}
+	for _, fn := range symtab.Funcs {
+		fn := fn
+		funcMap[uintptr(fn.Value)] = &fn
+	}
+	a.funcMap = funcMap
+	allTypes, err := a.types()
+	if err != nil {
+		return err
+	}
+	a.allTypes = allTypes
+	return err
+}
+
+// funcs returns the functions in the binary. They are decoded on the first call and shared by the following calls.
+func (a *analyzer) funcs() ([]*Function, error) {
+	a.funcsOnce.Do(func() {
+		a.allFuncs, a.funcsErr = a.loadFuncs()
+	})
+	return a.allFuncs, a.funcsErr
+}
+
+func (a *analyzer) loadFuncs() ([]*Function, error) {
+	symtab, err := a.gosymTable()
+	if err != nil {
+		return nil, err
+	}
+	var loadErr error
+	a.loadOnce.Do(func() {
+		loadErr = a.load()
+	})
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	text, err := a.obj.section(textSection)
+	if err != nil {
+		return nil, err
+	}
+	addr := text.addr
+	textdat := text.data
+	syms := a.allSyms
+	sigs := a.funcSignatures()
+	ssaBuilder := binaryssa.NewBuilder(a.allTypes, a.typeConv, binaryssa.WithSignatures(func(addr uint64) *types.Signature {
+		return sigs[addr]
+	}))
+	// build ssa.Function once for each function so that the callers and the callee share the same node in the call graph.
+	ssaFuncs := make(map[uint64]*ssa.Function, len(symtab.Funcs))
+	for _, fn := range symtab.Funcs {
+		ssaFuncs[fn.Entry] = ssaBuilder.BuildFunction(fn)
+	}
+	lookup := func(addr uint64) (string, uint64) {
+		i := sort.Search(len(syms), func(i int) bool { return addr < syms[i].Addr })
+		if i > 0 {
+			s := syms[i-1]
+			if s.Addr != 0 && s.Addr <= addr && addr < s.Addr+uint64(s.Size) {
+				return s.Name, s.Addr
+			}
+		}
+		return "", 0
+	}
+	arc, err := arch.Lookup(a.obj.arch())
+	if err != nil {
+		return nil, err
+	}
+	funcs := make([]*Function, 0, len(symtab.Funcs))
+	for _, fn := range symtab.Funcs {
+		fn := fn
+		start := fn.Entry - addr
+		end := fn.End - addr
+		mem := textdat[start:end]
+		pc := fn.Entry
+		funcV := &Function{SymFunc: &fn}
+		for pos := 0; pos < len(mem); {
+			inst, err := arc.Decode(mem[pos:], pc)
+			if err != nil {
+				// keep the bytes that cannot be decoded as "?" like go tool objdump, so Inst and Source cover the whole function.
+				size := arc.MinInstLen()
+				if size > len(mem)-pos {
+					size = len(mem) - pos
+				}
+				inst = arc.Unknown(pc, size)
+			}
+			if target, ok := arc.CallTarget(inst); ok {
+				if callee, found := ssaFuncs[target]; found {
+					funcV.Callee = append(funcV.Callee, callee)
+				}
+			}
+			if inst.Op == arch.UnknownOp {
+				funcV.Source = append(funcV.Source, arch.UnknownOp)
+			} else {
+				funcV.Source = append(funcV.Source, arc.GoSyntax(inst, lookup))
+			}
+			funcV.Inst = append(funcV.Inst, inst)
+			pos += inst.Len
+			pc += uint64(inst.Len)
+		}
+		funcV.SSAFunc = ssaFuncs[fn.Entry]
+		funcs = append(funcs, funcV)
+	}
+	return funcs, nil
+}
+
+// module returns the type data of the binary with its layout.
+func (a *analyzer) module() (*internalreflect.Layout, *internalreflect.Module, error) {
+	version, err := a.goVersion()
+	if err != nil {
+		return nil, nil, err
+	}
+	pclntab, err := a.obj.section(gopclntabSection)
+	if err != nil {
+		return nil, nil, err
+	}
+	bo := a.obj.byteOrder()
+	if !isPclntabHeader(pclntab.data, bo) {
+		return nil, nil, fmt.Errorf("failed to find pclntab header")
+	}
+	// the pointer size of the target architecture is recorded in the pclntab header.
+	layout, err := internalreflect.NewLayout(version, int(pclntab.data[7]))
+	if err != nil {
+		return nil, nil, err
+	}
+	rosect, err := a.obj.section(rodataSection)
+	if err != nil {
+		return nil, nil, err
+	}
+	md, err := a.moduledata()
+	if err != nil {
+		return nil, nil, err
+	}
+	return layout, internalreflect.NewModule(layout, rosect.addr, rosect.data, md.text, bo), nil
+}
+
+func (a *analyzer) types() ([]reflect.Type, error) {
+	layout, mod, err := a.module()
+	if err != nil {
+		return nil, err
+	}
+	typeOffsets, err := a.typeOffsets(layout, mod)
+	if err != nil {
+		return nil, err
+	}
+	types := make([]reflect.Type, 0, len(typeOffsets))
+	seen := map[uintptr]struct{}{}
+	for _, offset := range typeOffsets {
+		typ, err := mod.TypeByOffset(offset)
+		if err != nil {
+			return nil, err
+		}
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem().(*internalreflect.Type)
+		}
+		if _, exists := seen[typ.Addr()]; exists {
+			continue
+		}
+		seen[typ.Addr()] = struct{}{}
+		types = append(types, typ)
+	}
+	return types, nil
+}
+
+// typeOffsets returns the offsets of the types in the binary from the head of the type data.
+func (a *analyzer) typeOffsets(layout *internalreflect.Layout, mod *internalreflect.Module) ([]int32, error) {
+	if !layout.HasTypelinks() {
+		md, err := a.moduledata()
+		if err != nil {
+			return nil, err
+		}
+		return mod.TypeOffsets(md.typedesclen)
+	}
+	typelink, err := a.obj.section(typelinkSection)
+	if err != nil {
+		return nil, err
+	}
+	typedat := typelink.data
+	bo := a.obj.byteOrder()
+	typeOffsets := make([]int32, 0, len(typedat)/4)
+	for i := 0; i+4 <= len(typedat); i += 4 {
+		typeOffsets = append(typeOffsets, int32(bo.Uint32(typedat[i:])))
+	}
+	return typeOffsets, nil
+}
+
+func (a *analyzer) gosymTable() (*gosym.Table, error) {
+	symtab, err := a.obj.section(gosymtabSection)
+	if err != nil {
+		return nil, err
+	}
+	pclntab, err := a.obj.section(gopclntabSection)
+	if err != nil {
+		return nil, err
+	}
+	text, err := a.obj.section(textSection)
+	if err != nil {
+		return nil, err
+	}
+	pcln := gosym.NewLineTable(pclntab.data, text.addr)
+	tab, err := gosym.NewTable(symtab.data, pcln)
+	if err != nil {
+		return nil, err
+	}
+	return tab, nil
+}
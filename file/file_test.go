@@ -0,0 +1,70 @@
+package file_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+)
+
+func TestOpen(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		expect interface{}
+	}{
+		{name: "macho", expect: &file.MachOFile{}},
+		{name: "elf", expect: &file.ELFFile{}},
+		{name: "pe", expect: &file.PEFile{}},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			f, err := file.Open(filepath.Join("testdata", test.name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			switch test.expect.(type) {
+			case *file.MachOFile:
+				if _, ok := f.(*file.MachOFile); !ok {
+					t.Fatalf("expected *file.MachOFile but got %T", f)
+				}
+			case *file.ELFFile:
+				if _, ok := f.(*file.ELFFile); !ok {
+					t.Fatalf("expected *file.ELFFile but got %T", f)
+				}
+			case *file.PEFile:
+				if _, ok := f.(*file.PEFile); !ok {
+					t.Fatalf("expected *file.PEFile but got %T", f)
+				}
+			}
+			syms, err := f.Symbols()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(syms) == 0 {
+				t.Fatal("failed to get symbols")
+			}
+			types, err := f.Types()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(types) == 0 {
+				t.Fatal("failed to get types")
+			}
+		})
+	}
+}
+
+func TestNewFileUnknownFormat(t *testing.T) {
+	if _, err := file.NewFile(bytes.NewReader([]byte("not a binary"))); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+	if _, err := file.Open(filepath.Join("testdata", "main.go")); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+	if _, err := file.Open(filepath.Join("testdata", "not_exists")); !os.IsNotExist(err) {
+		t.Fatalf("expected not exist error but got %v", err)
+	}
+}
@@ -0,0 +1,345 @@
+package file
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// pcdataInlTreeIndex is the index of the pcdata that maps the pcs to the inlined calls ( runtime._PCDATA_InlTreeIndex ).
+	pcdataInlTreeIndex = 2
+	// funcdataInlTree is the index of the funcdata that points to the inlining tree ( runtime._FUNCDATA_InlTree ).
+	funcdataInlTree = 3
+)
+
+// noFuncdata is the value of funcInfo.funcdata that has no data.
+const noFuncdata = ^uint64(0)
+
+// funcInfo is a subset of runtime._func in the pclntab.
+type funcInfo struct {
+	entry uint64
+	// args is the size of the arguments in bytes. It is negative if it is unknown ( e.g. assembly functions ).
+	args int32
+	// pcfile and pcln are the offsets of the pc-value tables of the file and the line in the pctab.
+	pcfile, pcln uint32
+	// cuOffset is the index of the first file of the compilation unit in the cutab.
+	cuOffset uint32
+	// pcdata is the offsets of the pc-value tables in the pctab. An offset of 0 has no table.
+	pcdata []uint32
+	// funcdata is the addresses of the data before Go 1.18, and the offsets from moduledata.gofunc since Go 1.18.
+	funcdata []uint64
+}
+
+// pcHeader is the header of the pclntab ( runtime.pcHeader ) and the tables that it points to.
+type pcHeader struct {
+	bo      binary.ByteOrder
+	ptrSize int
+	// quantum is the unit of the pc deltas in the pc-value tables.
+	quantum uint64
+	magic   uint32
+	nfunc   uint64
+	// textStart is the base of the entries since Go 1.18.
+	textStart uint64
+	funcname  []byte
+	cutab     []byte
+	filetab   []byte
+	pctab     []byte
+	// functab is runtime.functab followed by the _func structures.
+	functab []byte
+}
+
+// pclnHeader returns the header of the pclntab in the binary.
+func (a *analyzer) pclnHeader() (*pcHeader, error) {
+	pclntab, err := a.obj.section(gopclntabSection)
+	if err != nil {
+		return nil, err
+	}
+	h, err := parsePCHeader(pclntab.data, a.obj.byteOrder())
+	if err != nil {
+		return nil, err
+	}
+	if h.magic != go116PclntabMagic && h.textStart == 0 {
+		// the linker leaves textStart zero since Go 1.26, and the entries are the offsets from the text section.
+		text, err := a.obj.section(textSection)
+		if err != nil {
+			return nil, err
+		}
+		h.textStart = text.addr
+	}
+	return h, nil
+}
+
+func parsePCHeader(pclntab []byte, bo binary.ByteOrder) (*pcHeader, error) {
+	if !isPclntabHeader(pclntab, bo) {
+		return nil, fmt.Errorf("failed to find pclntab header")
+	}
+	h := &pcHeader{bo: bo, ptrSize: int(pclntab[7]), quantum: uint64(pclntab[6]), magic: bo.Uint32(pclntab)}
+	// the header fields after the magic number and the sizes are pointer-sized words.
+	// Go 1.18 added textStart after nfiles, and the entries became the offsets from it.
+	fields := []string{"nfunc", "nfiles", "funcname", "cu", "filetab", "pctab", "pcln"}
+	if h.magic != go116PclntabMagic {
+		fields = []string{"nfunc", "nfiles", "textStart", "funcname", "cu", "filetab", "pctab", "pcln"}
+	}
+	words := map[string]uint64{}
+	for i, field := range fields {
+		off := 8 + i*h.ptrSize
+		if off+h.ptrSize > len(pclntab) {
+			return nil, fmt.Errorf("failed to read pclntab: offset %d is out of range", off)
+		}
+		if h.ptrSize == 4 {
+			words[field] = uint64(bo.Uint32(pclntab[off:]))
+		} else {
+			words[field] = bo.Uint64(pclntab[off:])
+		}
+	}
+	for _, table := range []struct {
+		field string
+		data  *[]byte
+	}{
+		{"funcname", &h.funcname},
+		{"cu", &h.cutab},
+		{"filetab", &h.filetab},
+		{"pctab", &h.pctab},
+		{"pcln", &h.functab},
+	} {
+		off := words[table.field]
+		if off > uint64(len(pclntab)) {
+			return nil, fmt.Errorf("failed to read pclntab: %s offset %d is out of range", table.field, off)
+		}
+		*table.data = pclntab[off:]
+	}
+	h.nfunc, h.textStart = words["nfunc"], words["textStart"]
+	return h, nil
+}
+
+func (h *pcHeader) word(data []byte, off int) (uint64, error) {
+	if off < 0 || off+h.ptrSize > len(data) {
+		return 0, fmt.Errorf("failed to read pclntab: offset %d is out of range", off)
+	}
+	if h.ptrSize == 4 {
+		return uint64(h.bo.Uint32(data[off:])), nil
+	}
+	return h.bo.Uint64(data[off:]), nil
+}
+
+func (h *pcHeader) u32(data []byte, off int) (uint32, error) {
+	if off < 0 || off+4 > len(data) {
+		return 0, fmt.Errorf("failed to read pclntab: offset %d is out of range", off)
+	}
+	return h.bo.Uint32(data[off:]), nil
+}
+
+// funcInfos returns the runtime._func of the functions keyed by entry.
+func (a *analyzer) funcInfos() (map[uint64]*funcInfo, error) {
+	h, err := a.pclnHeader()
+	if err != nil {
+		return nil, err
+	}
+	return h.funcInfos()
+}
+
+// funcInfos parses the function table ( runtime.functab ) that follows the pclntab header.
+func (h *pcHeader) funcInfos() (map[uint64]*funcInfo, error) {
+	infos := make(map[uint64]*funcInfo, h.nfunc)
+	for i := 0; i < int(h.nfunc); i++ {
+		var (
+			entry, funcOff uint64
+			err            error
+		)
+		if h.magic == go116PclntabMagic {
+			if entry, err = h.word(h.functab, 2*i*h.ptrSize); err != nil {
+				return nil, err
+			}
+			if funcOff, err = h.word(h.functab, (2*i+1)*h.ptrSize); err != nil {
+				return nil, err
+			}
+		} else {
+			entryOff, err := h.u32(h.functab, 8*i)
+			if err != nil {
+				return nil, err
+			}
+			off, err := h.u32(h.functab, 8*i+4)
+			if err != nil {
+				return nil, err
+			}
+			entry, funcOff = h.textStart+uint64(entryOff), uint64(off)
+		}
+		info, err := h.parseFunc(int(funcOff))
+		if err != nil {
+			return nil, err
+		}
+		info.entry = entry
+		infos[entry] = info
+	}
+	return infos, nil
+}
+
+// parseFunc parses runtime._func at off in the functab.
+//
+// Before Go 1.18, it starts with entry uintptr and the funcdata are aligned pointers.
+// Since Go 1.18, it starts with entryOff uint32 and the funcdata are uint32 offsets, and Go 1.20 added startLine.
+// The other fields are common: nameOff, args, deferreturn, pcsp, pcfile, pcln, npcdata and cuOffset are 4 bytes,
+// and nfuncdata is the last byte of the fixed part, followed by the pcdata offsets and the funcdata.
+func (h *pcHeader) parseFunc(off int) (*funcInfo, error) {
+	base, size := off+4, 40
+	switch h.magic {
+	case go116PclntabMagic:
+		base, size = off+h.ptrSize, h.ptrSize+36
+	case go120PclntabMagic:
+		size = 44
+	}
+	field := func(i int) (uint32, error) {
+		// the fields after nameOff.
+		return h.u32(h.functab, base+4+4*i)
+	}
+	var (
+		info = &funcInfo{}
+		v    [7]uint32
+	)
+	for i := range v {
+		var err error
+		if v[i], err = field(i); err != nil {
+			return nil, err
+		}
+	}
+	info.args = int32(v[0])
+	info.pcfile, info.pcln, info.cuOffset = v[3], v[4], v[6]
+	npcdata := int(v[5])
+	if off+size > len(h.functab) {
+		return nil, fmt.Errorf("failed to read pclntab: offset %d is out of range", off+size)
+	}
+	nfuncdata := int(h.functab[off+size-1])
+	pos := off + size
+	for i := 0; i < npcdata; i++ {
+		pcdata, err := h.u32(h.functab, pos)
+		if err != nil {
+			return nil, err
+		}
+		info.pcdata = append(info.pcdata, pcdata)
+		pos += 4
+	}
+	if h.magic == go116PclntabMagic && h.ptrSize == 8 && pos%8 != 0 {
+		pos += 4
+	}
+	for i := 0; i < nfuncdata; i++ {
+		if h.magic == go116PclntabMagic {
+			addr, err := h.word(h.functab, pos)
+			if err != nil {
+				return nil, err
+			}
+			if addr == 0 {
+				addr = noFuncdata
+			}
+			info.funcdata = append(info.funcdata, addr)
+			pos += h.ptrSize
+			continue
+		}
+		data, err := h.u32(h.functab, pos)
+		if err != nil {
+			return nil, err
+		}
+		if data == ^uint32(0) {
+			info.funcdata = append(info.funcdata, noFuncdata)
+		} else {
+			info.funcdata = append(info.funcdata, uint64(data))
+		}
+		pos += 4
+	}
+	return info, nil
+}
+
+// pcValue is the value of a pc-value table from the end of the previous range to end ( exclusive ).
+type pcValue struct {
+	end uint64
+	val int32
+}
+
+// pcTable is a decoded pc-value table sorted by pc.
+type pcTable []pcValue
+
+// lookup returns the value at pc.
+func (t pcTable) lookup(pc uint64) (int32, bool) {
+	lo, hi := 0, len(t)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if t[mid].end <= pc {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	if lo == len(t) {
+		return 0, false
+	}
+	return t[lo].val, true
+}
+
+// pcTable decodes the pc-value table at off in the pctab for the function at entry.
+// The table is a sequence of the pairs of the zigzag-encoded value delta and the pc delta in quantum, both are uvarint.
+func (h *pcHeader) pcTable(off uint32, entry uint64) (pcTable, error) {
+	if off == 0 {
+		return nil, nil
+	}
+	if int(off) >= len(h.pctab) {
+		return nil, fmt.Errorf("failed to read pctab: offset %d is out of range", off)
+	}
+	var (
+		t   pcTable
+		p   = h.pctab[off:]
+		val = int32(-1)
+		pc  = entry
+	)
+	for first := true; ; first = false {
+		uvdelta, n := binary.Uvarint(p)
+		if n <= 0 {
+			return nil, fmt.Errorf("failed to read pctab: invalid value delta at %d", off)
+		}
+		if uvdelta == 0 && !first {
+			return t, nil
+		}
+		p = p[n:]
+		if uvdelta&1 != 0 {
+			uvdelta = ^(uvdelta >> 1)
+		} else {
+			uvdelta >>= 1
+		}
+		pcdelta, n := binary.Uvarint(p)
+		if n <= 0 {
+			return nil, fmt.Errorf("failed to read pctab: invalid pc delta at %d", off)
+		}
+		p = p[n:]
+		val += int32(uvdelta)
+		pc += pcdelta * h.quantum
+		t = append(t, pcValue{end: pc, val: val})
+	}
+}
+
+// funcName returns the name at off in the funcnametab.
+func (h *pcHeader) funcName(off int32) string {
+	return cstring(h.funcname, int(off))
+}
+
+// fileName returns the name of the file at index in the compilation unit of info.
+func (h *pcHeader) fileName(info *funcInfo, index int32) (string, error) {
+	fileOff, err := h.u32(h.cutab, 4*(int(info.cuOffset)+int(index)))
+	if err != nil {
+		return "", err
+	}
+	if fileOff == ^uint32(0) {
+		return "", nil
+	}
+	return cstring(h.filetab, int(fileOff)), nil
+}
+
+// cstring returns the NUL-terminated string at off in data.
+func cstring(data []byte, off int) string {
+	if off < 0 || off >= len(data) {
+		return ""
+	}
+	for i := off; i < len(data); i++ {
+		if data[i] == 0 {
+			return string(data[off:i])
+		}
+	}
+	return string(data[off:])
+}
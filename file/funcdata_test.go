@@ -0,0 +1,87 @@
+package file_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	binaryssa "github.com/goccy/binarian/ssa"
+)
+
+func TestFunctionArgs(t *testing.T) {
+	lift := func(t *testing.T, path, name string) (*file.Function, *binaryssa.Function) {
+		t.Helper()
+		f, err := file.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		funcs, err := f.Funcs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fn := range funcs {
+			if fn.SymFunc.Name == name {
+				lifted, err := f.Lift(fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return fn, lifted
+			}
+		}
+		t.Fatalf("failed to find %s", name)
+		return nil, nil
+	}
+	t.Run("method", func(t *testing.T) {
+		_, fn := lift(t, filepath.Join("testdata", "elf"), "main.(*T).F")
+		if got := fmt.Sprint(fn.Args); got != "[arg0 *main.T (AX) arg1 int (BX)]" {
+			t.Fatalf("unexpected args %s", got)
+		}
+		if got := fmt.Sprint(fn.Results); got != "[r0 string (AX, BX)]" {
+			t.Fatalf("unexpected results %s", got)
+		}
+	})
+	t.Run("inferred", func(t *testing.T) {
+		fn, lifted := lift(t, filepath.Join("testdata", "elf"), "main.f")
+		// the interface value is passed in AX and BX.
+		if len(lifted.Args) != 2 {
+			t.Fatalf("unexpected args %v", lifted.Args)
+		}
+		for i, reg := range []string{"AX", "BX"} {
+			arg := lifted.Args[i]
+			if arg.Name != fmt.Sprintf("arg%d", i) || len(arg.Regs) != 1 || arg.Regs[0] != reg {
+				t.Fatalf("unexpected arg %s", arg)
+			}
+		}
+		var annotated bool
+		src := fn.ArgsSource(lifted)
+		for _, s := range src {
+			if strings.HasSuffix(s, "// arg0") {
+				annotated = true
+			}
+		}
+		if !annotated {
+			t.Fatalf("failed to find the annotation of arg0:\n%s", strings.Join(src, "\n"))
+		}
+		// Source itself is not annotated.
+		for _, s := range fn.Source {
+			if strings.Contains(s, "//") {
+				t.Fatalf("unexpected annotation in Source: %s", s)
+			}
+		}
+	})
+	t.Run("stack abi", func(t *testing.T) {
+		// the arguments are passed on the stack before Go 1.17.
+		_, fn := lift(t, filepath.Join("testdata", "goversion", "go1.16.15"), "runtime.memequal")
+		if len(fn.Args) != 3 {
+			t.Fatalf("unexpected args %v", fn.Args)
+		}
+		for _, arg := range fn.Args {
+			if len(arg.Regs) != 0 {
+				t.Fatalf("unexpected register arg %s", arg)
+			}
+		}
+	})
+}
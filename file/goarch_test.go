@@ -0,0 +1,85 @@
+package file_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"github.com/goccy/binarian/internal/arch"
+)
+
+// Fixtures in testdata/goarch are built from testdata/goversion/main.go for 32-bit and big endian targets with
+//
+//	GOOS=linux GOARCH=<arch> go build -trimpath -ldflags="-s -w" -o <arch> .
+func TestGoArch(t *testing.T) {
+	var paths []string
+	for _, arch := range []string{"386", "arm", "mips", "s390x"} {
+		paths = append(paths, filepath.Join("testdata", "goarch", arch))
+	}
+	testGoldenTypes(t, paths)
+}
+
+func TestGoArchFuncs(t *testing.T) {
+	for _, test := range []struct {
+		arch        string
+		unsupported bool
+		// noCFG is true if the control-flow graphs are not supported.
+		noCFG bool
+	}{
+		{arch: "386"},
+		{arch: "arm", noCFG: true},
+		// golang.org/x/arch has no decoder for mips.
+		{arch: "mips", unsupported: true},
+		{arch: "s390x", noCFG: true},
+	} {
+		test := test
+		t.Run(test.arch, func(t *testing.T) {
+			f, err := file.Open(filepath.Join("testdata", "goarch", test.arch))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			funcs, err := f.Funcs()
+			if test.unsupported {
+				if !errors.Is(err, arch.ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported but got %v", err)
+				}
+				if _, err := f.CallGraph(); !errors.Is(err, arch.ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported from CallGraph but got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var mainFunc *file.Function
+			for _, fn := range funcs {
+				if fn.SymFunc.Name == "main.main" {
+					mainFunc = fn
+				}
+			}
+			if mainFunc == nil {
+				t.Fatal("failed to find main.main")
+			}
+			if len(mainFunc.Inst) == 0 || len(mainFunc.Callee) == 0 {
+				t.Fatalf("failed to decode main.main: %d instructions and %d callees", len(mainFunc.Inst), len(mainFunc.Callee))
+			}
+			graph, err := f.CallGraph()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(graph.Nodes) == 0 {
+				t.Fatal("failed to create call graph")
+			}
+			_, err = f.CFG(mainFunc)
+			if test.noCFG {
+				if !errors.Is(err, arch.ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported from CFG but got %v", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
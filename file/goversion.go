@@ -0,0 +1,25 @@
+package file
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"io"
+
+	"github.com/goccy/binarian/internal/goversion"
+)
+
+// detectGoVersion returns the version of the Go toolchain that built the binary.
+// It is read from the build information embedded by the linker.
+// The magic number of the pclntab does not substitute for it, because the Go versions that share a magic number
+// have different layouts of the runtime data ( e.g. Go 1.20 and Go 1.24 ), and decoding with a wrong layout produces garbage.
+func detectGoVersion(r io.ReaderAt) (goversion.Version, error) {
+	info, err := buildinfo.Read(r)
+	if err != nil {
+		return goversion.Version{}, fmt.Errorf("failed to detect Go version: %w", err)
+	}
+	v, err := goversion.Parse(info.GoVersion)
+	if err != nil {
+		return goversion.Version{}, fmt.Errorf("failed to detect Go version: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,149 @@
+package file_test
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"github.com/goccy/binarian/reflect"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// goldenTypes is the unnamed types used by testdata/goversion/main.go.
+var goldenTypes = map[string]struct{}{
+	"[4]uint8":                           {},
+	"[]int":                              {},
+	"chan<- int":                         {},
+	"func(int, ...string) (bool, error)": {},
+}
+
+// Fixtures in testdata/goversion are built from testdata/goversion/main.go by each Go release with
+//
+//	GOOS=linux GOARCH=amd64 go build -trimpath -ldflags="-s -w" -o go1.X.Y .
+func TestGoVersion(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "goversion", "go1.*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGoldenTypes(t, paths)
+}
+
+func TestGoVersionWithoutBuildInfo(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "goversion", "go1.24.13"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the Go 1.24 binary has the same pclntab magic number as Go 1.20 but a different layout of the runtime data.
+	magic := []byte("\xff Go buildinf:")
+	if !bytes.Contains(data, magic) {
+		t.Fatal("failed to find the build information")
+	}
+	data = bytes.ReplaceAll(data, magic, make([]byte, len(magic)))
+	path := filepath.Join(t.TempDir(), "go1.24.13")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := file.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.Types(); err == nil || !strings.Contains(err.Error(), "failed to detect Go version") {
+		t.Fatalf("expected the error of the Go version but got %v", err)
+	}
+}
+
+// testGoldenTypes compares the types of each binary in paths with the golden file ( <path>.golden ).
+// Run go test with -update to regenerate golden files.
+func testGoldenTypes(t *testing.T, paths []string) {
+	t.Helper()
+	for _, path := range paths {
+		if filepath.Ext(path) == ".golden" {
+			continue
+		}
+		path := path
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			f, err := file.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			types, err := f.Types()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var lines []string
+			for _, typ := range types {
+				if _, exists := goldenTypes[typ.String()]; !exists && !strings.Contains(typ.String(), "main.") {
+					continue
+				}
+				lines = append(lines, describeType(typ))
+			}
+			sort.Strings(lines)
+			got := strings.Join(lines, "\n") + "\n"
+			golden := path + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != string(expected) {
+				t.Fatalf("unexpected types.\nexpected:\n%s\ngot:\n%s", expected, got)
+			}
+		})
+	}
+}
+
+func describeType(typ reflect.Type) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: kind=%s name=%q pkgPath=%q size=%d", typ.String(), typ.Kind(), typ.Name(), typ.PkgPath(), typ.Size())
+	switch typ.Kind() {
+	case reflect.Array:
+		fmt.Fprintf(&b, " elem=%s len=%d", typ.Elem(), typ.Len())
+	case reflect.Chan:
+		fmt.Fprintf(&b, " elem=%s dir=%d", typ.Elem(), typ.ChanDir())
+	case reflect.Func:
+		var in, out []string
+		for i := 0; i < typ.NumIn(); i++ {
+			in = append(in, typ.In(i).String())
+		}
+		for i := 0; i < typ.NumOut(); i++ {
+			out = append(out, typ.Out(i).String())
+		}
+		fmt.Fprintf(&b, " in=%v out=%v variadic=%t", in, out, typ.IsVariadic())
+	case reflect.Map:
+		fmt.Fprintf(&b, " key=%s elem=%s", typ.Key(), typ.Elem())
+	case reflect.Ptr, reflect.Slice:
+		fmt.Fprintf(&b, " elem=%s", typ.Elem())
+	case reflect.Struct:
+		fmt.Fprintf(&b, " fields=%d", typ.NumField())
+	}
+	if typ.Kind() == reflect.Struct {
+		for i := 0; i < typ.NumField(); i++ {
+			field := typ.Field(i)
+			fmt.Fprintf(&b, "\n\tfield %s %s pkgPath=%q tag=%q offset=%d index=%v anonymous=%t",
+				field.Name, field.Type, field.PkgPath, field.Tag, field.Offset, field.Index, field.Anonymous,
+			)
+		}
+	}
+	for i := 0; i < typ.NumMethod(); i++ {
+		mtd := typ.Method(i)
+		var mtyp string
+		if mtd.Type != nil {
+			mtyp = mtd.Type.String()
+		}
+		fmt.Fprintf(&b, "\n\tmethod %s %s pkgPath=%q", mtd.Name, mtyp, mtd.PkgPath)
+	}
+	return b.String()
+}
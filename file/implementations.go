@@ -0,0 +1,96 @@
+package file
+
+import (
+	"sort"
+
+	internalreflect "github.com/goccy/binarian/internal/reflect"
+	"github.com/goccy/binarian/reflect"
+)
+
+// Implementations is the interface implementation matrix of a binary.
+// Interfaces without methods are excluded because every type implements them.
+type Implementations struct {
+	// Interfaces is every interface type with the concrete types that implement it.
+	Interfaces []*InterfaceImplementation
+	// Types is every concrete type that implements at least one of the interface types.
+	Types []*TypeImplementation
+}
+
+// InterfaceImplementation is an interface type and the concrete types whose method sets satisfy it.
+type InterfaceImplementation struct {
+	Interface    reflect.Type
+	Implementers []reflect.Type
+}
+
+// TypeImplementation is a concrete type and the interface types it implements.
+type TypeImplementation struct {
+	Type       reflect.Type
+	Interfaces []reflect.Type
+}
+
+// implementations returns the interface implementation matrix. It is computed once and shared by the following calls.
+func (a *analyzer) implementations() (*Implementations, error) {
+	a.implsOnce.Do(func() {
+		a.impls, a.implsErr = a.loadImplementations()
+	})
+	return a.impls, a.implsErr
+}
+
+func (a *analyzer) loadImplementations() (*Implementations, error) {
+	types, err := a.types()
+	if err != nil {
+		return nil, err
+	}
+	var (
+		ifaces   []reflect.Type
+		concrete []reflect.Type
+	)
+	for _, typ := range types {
+		if typ.Kind() == reflect.Interface {
+			if typ.NumMethod() > 0 {
+				ifaces = append(ifaces, typ)
+			}
+			continue
+		}
+		if typ.Name() == "" {
+			continue
+		}
+		concrete = append(concrete, typ)
+		// the method set of *T includes the methods of T.
+		if ptr := internalreflect.PtrTo(typ); ptr != nil {
+			concrete = append(concrete, ptr)
+		}
+	}
+	sortTypes(ifaces)
+	sortTypes(concrete)
+
+	impls := &Implementations{}
+	typeImpls := make([]*TypeImplementation, len(concrete))
+	for i, typ := range concrete {
+		typeImpls[i] = &TypeImplementation{Type: typ}
+	}
+	for _, iface := range ifaces {
+		impl := &InterfaceImplementation{Interface: iface}
+		for i, typ := range concrete {
+			if !typ.Implements(iface) {
+				continue
+			}
+			impl.Implementers = append(impl.Implementers, typ)
+			typeImpls[i].Interfaces = append(typeImpls[i].Interfaces, iface)
+		}
+		impls.Interfaces = append(impls.Interfaces, impl)
+	}
+	for _, typeImpl := range typeImpls {
+		if len(typeImpl.Interfaces) == 0 {
+			continue
+		}
+		impls.Types = append(impls.Types, typeImpl)
+	}
+	return impls, nil
+}
+
+func sortTypes(types []reflect.Type) {
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].String() < types[j].String()
+	})
+}
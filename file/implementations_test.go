@@ -0,0 +1,73 @@
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"github.com/goccy/binarian/reflect"
+)
+
+func TestImplementations(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "goversion", "go1.27.1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	impls, err := f.Implementations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	implementers := map[string][]string{}
+	for _, impl := range impls.Interfaces {
+		if impl.Interface.Kind() != reflect.Interface || impl.Interface.NumMethod() == 0 {
+			t.Fatalf("unexpected interface %s", impl.Interface)
+		}
+		implementers[impl.Interface.String()] = typeNames(impl.Implementers)
+	}
+	if got := implementers["main.Iface"]; !equalStrings(got, []string{"*main.Struct"}) {
+		t.Fatalf("unexpected implementers of main.Iface: %v", got)
+	}
+	if got := implementers["main.Stringer"]; !containsString(got, "main.Named") || !containsString(got, "*main.Named") {
+		t.Fatalf("unexpected implementers of main.Stringer: %v", got)
+	}
+	ifaces := map[string][]string{}
+	for _, impl := range impls.Types {
+		ifaces[impl.Type.String()] = typeNames(impl.Interfaces)
+	}
+	if got := ifaces["*main.Struct"]; !containsString(got, "main.Iface") {
+		t.Fatalf("unexpected interfaces of *main.Struct: %v", got)
+	}
+	if got := ifaces["main.Struct"]; len(got) != 0 {
+		t.Fatalf("unexpected interfaces of main.Struct: %v", got)
+	}
+}
+
+func typeNames(types []reflect.Type) []string {
+	names := make([]string, 0, len(types))
+	for _, typ := range types {
+		names = append(names, typ.String())
+	}
+	return names
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
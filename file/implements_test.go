@@ -0,0 +1,65 @@
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	internalreflect "github.com/goccy/binarian/internal/reflect"
+)
+
+func TestImplements(t *testing.T) {
+	for _, version := range []string{"go1.16.15", "go1.27.1"} {
+		version := version
+		t.Run(version, func(t *testing.T) {
+			testImplements(t, filepath.Join("testdata", "goversion", version))
+		})
+	}
+}
+
+func testImplements(t *testing.T, path string) {
+	f, err := file.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var (
+		named     = findType(t, f, "main.Named")
+		strct     = findType(t, f, "main.Struct")
+		iface     = findType(t, f, "main.Iface")
+		stringer  = findType(t, f, "main.Stringer")
+		intSlice  = findType(t, f, "[]int")
+		ptrStruct = internalreflect.PtrTo(strct)
+		ptrNamed  = strct.Field(3).Type.Elem() // Table map[string]*Named
+		intType   = strct.Field(0).Type.Field(0).Type
+	)
+	if ptrStruct == nil {
+		t.Fatal("failed to get *main.Struct")
+	}
+	for _, test := range []struct {
+		name string
+		got  bool
+		want bool
+	}{
+		{"Named implements Stringer", named.Implements(stringer), true},
+		{"*Named implements Stringer", ptrNamed.Implements(stringer), true},
+		{"Named implements Iface", named.Implements(iface), false},
+		{"Struct implements Iface", strct.Implements(iface), false},
+		{"*Struct implements Iface", ptrStruct.Implements(iface), true},
+		{"Iface implements Iface", iface.Implements(iface), true},
+		{"Stringer implements Iface", stringer.Implements(iface), false},
+		{"Named assignable to Stringer", named.AssignableTo(stringer), true},
+		{"Named assignable to int", named.AssignableTo(intType), false},
+		{"int assignable to Named", intType.AssignableTo(named), false},
+		{"[]int assignable to []int", intSlice.AssignableTo(intSlice), true},
+		{"Named convertible to int", named.ConvertibleTo(intType), true},
+		{"int convertible to Named", intType.ConvertibleTo(named), true},
+		{"[]int convertible to int", intSlice.ConvertibleTo(intType), false},
+		{"Struct convertible to Iface", strct.ConvertibleTo(iface), false},
+		{"*Struct convertible to Iface", ptrStruct.ConvertibleTo(iface), true},
+	} {
+		if test.got != test.want {
+			t.Errorf("%s: expected %t but got %t", test.name, test.want, test.got)
+		}
+	}
+}
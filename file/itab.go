@@ -0,0 +1,77 @@
+package file
+
+import (
+	"encoding/binary"
+
+	internalreflect "github.com/goccy/binarian/internal/reflect"
+	"github.com/goccy/binarian/reflect"
+)
+
+// Itab is an interface table generated by the compiler for a conversion of a concrete type to a non-empty interface type.
+// Unlike Implements, it shows the pairs of types that are actually converted in the binary.
+type Itab struct {
+	// Addr is the virtual address of the itab ( go:itab.T,I symbol ).
+	Addr      uint64
+	Interface reflect.Type
+	Type      reflect.Type
+	// Funcs is the code address of the method of Type called for each method of Interface in the order of Interface.Method(i).
+	// It is empty if Type does not implement Interface.
+	Funcs []uint64
+}
+
+func (a *analyzer) itabs() ([]*Itab, error) {
+	layout, mod, err := a.module()
+	if err != nil {
+		return nil, err
+	}
+	var itabs []*internalreflect.Itab
+	if layout.HasItablinks() {
+		itablink, err := a.obj.section(itablinkSection)
+		if err != nil {
+			return nil, err
+		}
+		itabs, err = itabsByItablinks(mod, itablink.data, a.obj.byteOrder(), layout)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		md, err := a.moduledata()
+		if err != nil {
+			return nil, err
+		}
+		itabs, err = mod.Itabs(md.itaboffset, md.itabsize)
+		if err != nil {
+			return nil, err
+		}
+	}
+	results := make([]*Itab, 0, len(itabs))
+	for _, itab := range itabs {
+		results = append(results, &Itab{
+			Addr:      itab.Addr,
+			Interface: itab.Inter,
+			Type:      itab.Type,
+			Funcs:     itab.Fun,
+		})
+	}
+	return results, nil
+}
+
+// itabsByItablinks decodes the itabs that the itablinks table points to.
+func itabsByItablinks(mod *internalreflect.Module, itablinks []byte, bo binary.ByteOrder, layout *internalreflect.Layout) ([]*internalreflect.Itab, error) {
+	ptrSize := layout.PtrSize()
+	itabs := make([]*internalreflect.Itab, 0, len(itablinks)/ptrSize)
+	for i := 0; i+ptrSize <= len(itablinks); i += ptrSize {
+		var addr uint64
+		if ptrSize == 4 {
+			addr = uint64(bo.Uint32(itablinks[i:]))
+		} else {
+			addr = bo.Uint64(itablinks[i:])
+		}
+		itab, err := mod.ItabByAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		itabs = append(itabs, itab)
+	}
+	return itabs, nil
+}
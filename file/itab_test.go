@@ -0,0 +1,80 @@
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+)
+
+func TestItabs(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		typ      string
+		iface    string
+		funcName string
+	}{
+		// itabs are found through itablinks.
+		{
+			path:     filepath.Join("testdata", "goversion", "go1.16.15"),
+			typ:      "runtime.errorString",
+			iface:    "error",
+			funcName: "runtime.(*errorString).Error",
+		},
+		// itabs are laid out in the type data.
+		{
+			path:     filepath.Join("testdata", "goversion", "go1.27.1"),
+			typ:      "*main.Struct",
+			iface:    "main.Iface",
+			funcName: "runtime.unreachableMethod", // the call of Method is devirtualized.
+		},
+		// the method table follows hash without padding on 32-bit architectures.
+		{
+			path:     filepath.Join("testdata", "goarch", "386"),
+			typ:      "*main.Struct",
+			iface:    "main.Iface",
+			funcName: "runtime.unreachableMethod",
+		},
+	} {
+		test := test
+		t.Run(test.path, func(t *testing.T) {
+			f, err := file.Open(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			itabs, err := f.Itabs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var found *file.Itab
+			for _, itab := range itabs {
+				if len(itab.Funcs) != itab.Interface.NumMethod() {
+					t.Fatalf("unexpected number of methods of itab %s,%s: %d", itab.Type, itab.Interface, len(itab.Funcs))
+				}
+				if itab.Type.String() == test.typ && itab.Interface.String() == test.iface {
+					found = itab
+				}
+			}
+			if found == nil {
+				t.Fatalf("failed to find itab %s,%s", test.typ, test.iface)
+			}
+			if !found.Type.Implements(found.Interface) {
+				t.Fatalf("expected %s to implement %s", test.typ, test.iface)
+			}
+			funcs, err := f.Funcs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, fn := range funcs {
+				if fn.SymFunc.Entry == found.Funcs[0] {
+					if fn.SymFunc.Name != test.funcName {
+						t.Fatalf("unexpected method %s", fn.SymFunc.Name)
+					}
+					return
+				}
+			}
+			t.Fatalf("failed to find function at %#x", found.Funcs[0])
+		})
+	}
+}
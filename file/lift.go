@@ -0,0 +1,75 @@
+package file
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/goccy/binarian/internal/arch"
+	"github.com/goccy/binarian/internal/goversion"
+	binaryssa "github.com/goccy/binarian/ssa"
+	"golang.org/x/tools/go/ssa"
+)
+
+// lift lifts the machine code of fn into SSA form.
+// The signature of fn types the parameters and the results, and the callees of the direct calls are resolved to the functions of the binary.
+func (a *analyzer) lift(fn *Function) (*binaryssa.Function, error) {
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	arc, err := arch.Lookup(a.obj.arch())
+	if err != nil {
+		return nil, err
+	}
+	funcByEntry := make(map[uint64]*ssa.Function, len(funcs))
+	for _, f := range funcs {
+		funcByEntry[f.SymFunc.Entry] = f.SSAFunc
+	}
+	if funcByEntry[fn.SymFunc.Entry] != fn.SSAFunc {
+		return nil, fmt.Errorf("failed to lift %s: the function is not in the binary", fn.SymFunc.Name)
+	}
+	infos, err := a.funcInfos()
+	if err != nil {
+		return nil, err
+	}
+	version, err := a.goVersion()
+	if err != nil {
+		return nil, err
+	}
+	return binaryssa.Lift(arc, fn.SymFunc.Name, fn.SSAFunc.Signature, fn.Inst, fn.SymFunc.End, func(addr uint64) *ssa.Function {
+		return funcByEntry[addr]
+	}, a.liftOptions(infos[fn.SymFunc.Entry], version)...)
+}
+
+// liftOptions returns the options to lift the function described by info in the binary built by the Go toolchain of version.
+// info is nil if the function is not in the function table.
+func (a *analyzer) liftOptions(info *funcInfo, version goversion.Version) []binaryssa.LiftOption {
+	opts := []binaryssa.LiftOption{binaryssa.WithMemory(a.obj.readAddr)}
+	if !version.AtLeast(17) {
+		// the register ABI was introduced in Go 1.17.
+		opts = append(opts, binaryssa.WithABI0())
+	}
+	if info != nil && info.args >= 0 {
+		opts = append(opts, binaryssa.WithArgsSize(int(info.args)))
+	}
+	return opts
+}
+
+// ArgsSource returns Source annotated with the names of the arguments that each instruction reads before they are overwritten
+// ( e.g. MOVQ AX, 0x8(SP) // arg0 ). lifted is fn lifted by File.Lift.
+func (fn *Function) ArgsSource(lifted *binaryssa.Function) []string {
+	src := make([]string, 0, len(fn.Source))
+	for i, s := range fn.Source {
+		if i < len(fn.Inst) {
+			if args := lifted.ArgsAt(fn.Inst[i].PC); len(args) > 0 {
+				names := make([]string, 0, len(args))
+				for _, arg := range args {
+					names = append(names, arg.Name)
+				}
+				s += " // " + strings.Join(names, ", ")
+			}
+		}
+		src = append(src, s)
+	}
+	return src
+}
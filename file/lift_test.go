@@ -0,0 +1,117 @@
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	binaryssa "github.com/goccy/binarian/ssa"
+)
+
+func TestLift(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "elf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lift := func(name string) *binaryssa.Function {
+		t.Helper()
+		funcs, err := f.Funcs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fn := range funcs {
+			if fn.SymFunc.Name == name {
+				lifted, err := f.Lift(fn)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return lifted
+			}
+		}
+		t.Fatalf("failed to find %s", name)
+		return nil
+	}
+	t.Run("typed parameters", func(t *testing.T) {
+		fn := lift("main.(*T).F")
+		// the receiver and the argument are passed in AX and BX.
+		if len(fn.Params) < 2 {
+			t.Fatalf("unexpected params %v", fn.Params)
+		}
+		for i, expected := range []struct{ name, typ string }{{"AX", "*main.T"}, {"BX", "int"}} {
+			p := fn.Params[i]
+			if p.Name() != expected.name || p.Type() == nil || p.Type().String() != expected.typ {
+				t.Fatalf("expected %s %s but got %s %v", expected.name, expected.typ, p.Name(), p.Type())
+			}
+		}
+		if len(liftedCalls(fn, "Sprint")) != 1 {
+			t.Fatalf("failed to find the call of fmt.Sprint:\n%s", fn)
+		}
+	})
+	t.Run("indirect call", func(t *testing.T) {
+		fn := lift("main.f")
+		var found bool
+		for _, call := range liftedCalls(fn, "") {
+			// the method of the itab in AX.
+			if call.Value != nil && call.Func == nil {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("failed to find the indirect call:\n%s", fn)
+		}
+	})
+	t.Run("loop", func(t *testing.T) {
+		fn := lift("strconv.formatBits")
+		if fn.Blocks[0].Preds != nil {
+			t.Fatal("the entry must have no predecessors")
+		}
+		var phis, ifs int
+		for _, b := range fn.Blocks {
+			for i, instr := range b.Instrs {
+				switch instr.(type) {
+				case *binaryssa.Phi:
+					phis++
+					if len(instr.(*binaryssa.Phi).Edges) != len(b.Preds) {
+						t.Fatalf("unexpected phi %s in block %d", instr, b.Index)
+					}
+				case *binaryssa.If:
+					ifs++
+					if len(b.Succs) != 2 {
+						t.Fatalf("unexpected successors of block %d", b.Index)
+					}
+				}
+				if instr.Block() != b {
+					t.Fatalf("unexpected block of %s", instr)
+				}
+				if i == len(b.Instrs)-1 {
+					switch instr.(type) {
+					case *binaryssa.Jump, *binaryssa.If, *binaryssa.Return, *binaryssa.IndirectJump, *binaryssa.Trap:
+					default:
+						t.Fatalf("block %d ends with %s", b.Index, instr)
+					}
+				}
+			}
+		}
+		if phis == 0 || ifs == 0 {
+			t.Fatalf("expected phi nodes and branches:\n%s", fn)
+		}
+	})
+}
+
+// liftedCalls returns the calls in fn. If name is not empty, only the direct calls of the function named name are returned.
+func liftedCalls(fn *binaryssa.Function, name string) []*binaryssa.Call {
+	var calls []*binaryssa.Call
+	for _, b := range fn.Blocks {
+		for _, instr := range b.Instrs {
+			call, ok := instr.(*binaryssa.Call)
+			if !ok {
+				continue
+			}
+			if name == "" || (call.Func != nil && call.Func.Name() == name) {
+				calls = append(calls, call)
+			}
+		}
+	}
+	return calls
+}
@@ -0,0 +1,259 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxInlineDepth is the maximum number of the inlined calls to follow from an instruction.
+const maxInlineDepth = 64
+
+// Frame is the source position of an instruction in a function.
+type Frame struct {
+	// Func is the name of the function in the source code. It is the inlined function unless the frame is the last one.
+	Func string
+	File string
+	Line int
+}
+
+func (f Frame) String() string {
+	return fmt.Sprintf("%s:%d %s", f.File, f.Line, f.Func)
+}
+
+// PCRange is the addresses from Start to End ( exclusive ) of the instructions in Func.
+type PCRange struct {
+	Start, End uint64
+	Func       *Function
+}
+
+// inlinedCall is a subset of runtime.inlinedCall.
+type inlinedCall struct {
+	name string
+	// parentPc is the offset from the entry of an instruction whose source position is the call site.
+	parentPc int32
+}
+
+// frameDecoder decodes the source positions of the instructions from the pc-value tables of the file, the line and the inlining tree.
+// It is not modified after it is created, so the functions can be decoded concurrently.
+type frameDecoder struct {
+	obj   object
+	h     *pcHeader
+	infos map[uint64]*funcInfo
+	// gofunc is the base of the funcdata since Go 1.18. The inlined calls are not decoded if it is unknown.
+	gofunc uint64
+}
+
+// frames returns the source positions of the instructions of fn. Frames[i] starts with the innermost inlined function
+// of fn.Inst[i] followed by the call sites, and ends with the function itself.
+// They are decoded from the pclntab on each call, so Funcs does not pay for them.
+func (a *analyzer) frames(fn *Function) ([][]Frame, error) {
+	d, err := a.frameDecoder()
+	if err != nil {
+		return nil, err
+	}
+	return d.frames(fn)
+}
+
+// frameDecoder returns the frameDecoder created once and shared by the following calls.
+func (a *analyzer) frameDecoder() (*frameDecoder, error) {
+	a.frameDecOnce.Do(func() {
+		a.frameDec, a.frameDecErr = a.newFrameDecoder()
+	})
+	return a.frameDec, a.frameDecErr
+}
+
+func (a *analyzer) newFrameDecoder() (*frameDecoder, error) {
+	infos, err := a.funcInfos()
+	if err != nil {
+		return nil, err
+	}
+	h, err := a.pclnHeader()
+	if err != nil {
+		return nil, err
+	}
+	d := &frameDecoder{obj: a.obj, h: h, infos: infos}
+	if h.magic != go116PclntabMagic {
+		// the inlined calls are not decoded without runtime.moduledata.
+		if md, err := a.moduledata(); err == nil {
+			d.gofunc = md.gofunc
+		}
+	}
+	return d, nil
+}
+
+// frames returns the source positions of the instructions of fn. The innermost inlined function comes first, and the function itself is the last.
+// It returns nil if the pclntab has no function at the entry of fn.
+func (d *frameDecoder) frames(fn *Function) ([][]Frame, error) {
+	info, exists := d.infos[fn.SymFunc.Entry]
+	if !exists {
+		return nil, nil
+	}
+	fileTable, err := d.h.pcTable(info.pcfile, info.entry)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := d.h.pcTable(info.pcln, info.entry)
+	if err != nil {
+		return nil, err
+	}
+	inlIndex, inlTree, err := d.inlTree(info)
+	if err != nil {
+		return nil, err
+	}
+	calls := map[int32]*inlinedCall{}
+	// files interns the file names shared by the frames.
+	files := map[string]string{}
+	position := func(pc uint64) (string, int, error) {
+		index, ok := fileTable.lookup(pc)
+		if !ok {
+			return "", 0, nil
+		}
+		file, err := d.h.fileName(info, index)
+		if err != nil {
+			return "", 0, err
+		}
+		if interned, exists := files[file]; exists {
+			file = interned
+		} else {
+			files[file] = file
+		}
+		line, _ := lines.lookup(pc)
+		return file, int(line), nil
+	}
+	frames := make([][]Frame, len(fn.Inst))
+	for i, inst := range fn.Inst {
+		pc := inst.PC
+		for depth := 0; depth < maxInlineDepth; depth++ {
+			file, line, err := position(pc)
+			if err != nil {
+				return nil, err
+			}
+			index, ok := inlIndex.lookup(pc)
+			if !ok || index < 0 {
+				frames[i] = append(frames[i], Frame{Func: fn.SymFunc.Name, File: file, Line: line})
+				break
+			}
+			call, exists := calls[index]
+			if !exists {
+				if call, err = d.inlinedCall(inlTree, index); err != nil {
+					return nil, err
+				}
+				calls[index] = call
+			}
+			frames[i] = append(frames[i], Frame{Func: call.name, File: file, Line: line})
+			pc = info.entry + uint64(call.parentPc)
+		}
+	}
+	return frames, nil
+}
+
+// inlTree returns the pc-value table of the indices of the inlined calls and the address of the inlining tree of info.
+func (d *frameDecoder) inlTree(info *funcInfo) (pcTable, uint64, error) {
+	if len(info.pcdata) <= pcdataInlTreeIndex || len(info.funcdata) <= funcdataInlTree || info.funcdata[funcdataInlTree] == noFuncdata {
+		return nil, 0, nil
+	}
+	addr := info.funcdata[funcdataInlTree]
+	if d.h.magic != go116PclntabMagic {
+		if d.gofunc == 0 {
+			return nil, 0, nil
+		}
+		addr += d.gofunc
+	}
+	index, err := d.h.pcTable(info.pcdata[pcdataInlTreeIndex], info.entry)
+	if err != nil {
+		return nil, 0, err
+	}
+	return index, addr, nil
+}
+
+// inlinedCall reads the inlined call at index in the inlining tree at addr.
+//
+// Before Go 1.20, runtime.inlinedCall is parent int16, funcID uint8, _ byte, file, line, func_ and parentPc int32.
+// Since Go 1.20, it is funcID uint8, _ [3]byte, nameOff, parentPc and startLine int32.
+func (d *frameDecoder) inlinedCall(addr uint64, index int32) (*inlinedCall, error) {
+	size, nameOff, parentPc := 20, 12, 16
+	if d.h.magic == go120PclntabMagic {
+		size, nameOff, parentPc = 16, 4, 8
+	}
+	data, err := d.obj.readAddr(addr+uint64(index)*uint64(size), size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read inlined call %d: %w", index, err)
+	}
+	return &inlinedCall{
+		name:     d.h.funcName(int32(d.h.bo.Uint32(data[nameOff:]))),
+		parentPc: int32(d.h.bo.Uint32(data[parentPc:])),
+	}, nil
+}
+
+// lineToPCRanges returns the ranges of the instructions generated from the line of the file.
+// file matches the path in the binary or its suffix after a slash ( e.g. main.go ).
+// The instructions of the functions inlined at the line are included, and the functions whose positions cannot be decoded are skipped.
+func (a *analyzer) lineToPCRanges(file string, line int) ([]*PCRange, error) {
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	d, err := a.frameDecoder()
+	if err != nil {
+		return nil, err
+	}
+	var ranges []*PCRange
+	for _, fn := range funcs {
+		frames, err := d.frames(fn)
+		if err != nil {
+			continue
+		}
+		var cur *PCRange
+		for i, inst := range fn.Inst {
+			if i >= len(frames) || !framesAt(frames[i], file, line) {
+				cur = nil
+				continue
+			}
+			end := inst.PC + uint64(inst.Len)
+			if cur != nil {
+				cur.End = end
+				continue
+			}
+			cur = &PCRange{Start: inst.PC, End: end, Func: fn}
+			ranges = append(ranges, cur)
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].Start < ranges[j].Start
+	})
+	return ranges, nil
+}
+
+func framesAt(frames []Frame, file string, line int) bool {
+	for _, frame := range frames {
+		if frame.Line == line && (frame.File == file || strings.HasSuffix(frame.File, "/"+file)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnotatedSource returns Source annotated with the source positions. frames is the positions of fn returned by File.Frames.
+// The position of the following instructions ( e.g. "main.go:10 main.f" ) is inserted where it changes,
+// and the call sites of the inlined function follow it ( e.g. "strconv/itoa.go:35 strconv.Itoa ( inlined at main.go:10 main.f )" ).
+func (fn *Function) AnnotatedSource(frames [][]Frame) []string {
+	var (
+		lines []string
+		prev  string
+	)
+	for i, src := range fn.Source {
+		if i < len(frames) && len(frames[i]) > 0 {
+			pos := frames[i][0].String()
+			for _, caller := range frames[i][1:] {
+				pos += " ( inlined at " + caller.String() + " )"
+			}
+			if pos != prev {
+				lines = append(lines, pos)
+				prev = pos
+			}
+		}
+		lines = append(lines, "\t"+src)
+	}
+	return lines
+}
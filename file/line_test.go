@@ -0,0 +1,112 @@
+package file_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+)
+
+func TestLine(t *testing.T) {
+	open := func(t *testing.T, path string) (file.File, map[string]*file.Function) {
+		t.Helper()
+		f, err := file.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		funcs, err := f.Funcs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		byName := map[string]*file.Function{}
+		for _, fn := range funcs {
+			byName[fn.SymFunc.Name] = fn
+		}
+		return f, byName
+	}
+	t.Run("frames", func(t *testing.T) {
+		f, funcs := open(t, filepath.Join("testdata", "elf"))
+		fn, exists := funcs["main.main"]
+		if !exists {
+			t.Fatal("failed to find main.main")
+		}
+		fnFrames, err := f.Frames(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fnFrames) != len(fn.Inst) {
+			t.Fatalf("expected %d frames but got %d", len(fn.Inst), len(fnFrames))
+		}
+		lines := map[int]bool{}
+		for i, frames := range fnFrames {
+			if len(frames) != 1 {
+				t.Fatalf("unexpected frames at %#x: %v", fn.Inst[i].PC, frames)
+			}
+			frame := frames[0]
+			if frame.Func != "main.main" || !strings.HasSuffix(frame.File, "/main.go") {
+				t.Fatalf("unexpected frame at %#x: %s", fn.Inst[i].PC, frame)
+			}
+			lines[frame.Line] = true
+		}
+		for _, line := range []int{21, 22, 23} {
+			if !lines[line] {
+				t.Fatalf("failed to find main.go:%d", line)
+			}
+		}
+		annotated := strings.Join(fn.AnnotatedSource(fnFrames), "\n")
+		if !strings.Contains(annotated, "main.go:22 main.main\n\tLEAQ") {
+			t.Fatalf("unexpected annotated source:\n%s", annotated)
+		}
+	})
+	t.Run("inlined", func(t *testing.T) {
+		for _, test := range []struct {
+			path            string
+			caller, inlined string
+		}{
+			{filepath.Join("testdata", "elf"), "strconv.FormatInt", "strconv.small"},
+			{filepath.Join("testdata", "goversion", "go1.27.1"), "internal/abi.Name.IsBlank", "internal/abi.Name.ReadVarint"},
+			{filepath.Join("testdata", "goarch", "386"), "internal/abi.Name.IsBlank", "internal/abi.Name.ReadVarint"},
+		} {
+			f, funcs := open(t, test.path)
+			fn, exists := funcs[test.caller]
+			if !exists {
+				t.Fatalf("failed to find %s in %s", test.caller, test.path)
+			}
+			fnFrames, err := f.Frames(fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var inlined bool
+			for _, frames := range fnFrames {
+				if len(frames) > 1 && frames[0].Func == test.inlined && frames[len(frames)-1].Func == test.caller {
+					inlined = true
+				}
+			}
+			if !inlined {
+				t.Fatalf("failed to find %s inlined in %s of %s:\n%s", test.inlined, test.caller, test.path, strings.Join(fn.AnnotatedSource(fnFrames), "\n"))
+			}
+		}
+	})
+	t.Run("line to pc ranges", func(t *testing.T) {
+		f, funcs := open(t, filepath.Join("testdata", "elf"))
+		ranges, err := f.LineToPCRanges("main.go", 22)
+		if err != nil {
+			t.Fatal(err)
+		}
+		main := funcs["main.main"]
+		var found bool
+		for _, r := range ranges {
+			if r.Start >= r.End {
+				t.Fatalf("invalid range %#x-%#x", r.Start, r.End)
+			}
+			if r.Func == main {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatalf("failed to find the range of main.main in %d ranges", len(ranges))
+		}
+	})
+}
@@ -1,72 +1,136 @@
 package file
 
 import (
-	"bytes"
-	"debug/gosym"
+	"debug/dwarf"
 	"debug/macho"
 	"encoding/binary"
 	"fmt"
+	"go/types"
+	"io"
 	"os"
 	"sort"
-	"sync"
 
-	internalreflect "github.com/goccy/binarian/internal/reflect"
+	binarycfg "github.com/goccy/binarian/cfg"
 	"github.com/goccy/binarian/reflect"
 	binaryssa "github.com/goccy/binarian/ssa"
-	"golang.org/x/arch/x86/x86asm"
 	"golang.org/x/tools/go/callgraph"
-	"golang.org/x/tools/go/ssa"
 )
 
 type MachOFile struct {
 	File     *macho.File
-	rawFile  *os.File
-	allSyms  []Sym
-	allTypes []reflect.Type
-	funcMap  map[uintptr]*gosym.Func
-	loadOnce sync.Once
+	rawFile  io.ReaderAt
+	closer   io.Closer
+	analyzer *analyzer
 }
 
 func NewMachOFile(f *os.File) (*MachOFile, error) {
-	bin, err := macho.NewFile(f)
+	return newMachOFile(f)
+}
+
+func newMachOFile(r io.ReaderAt) (*MachOFile, error) {
+	bin, err := macho.NewFile(r)
 	if err != nil {
 		return nil, err
 	}
-	return &MachOFile{
+	file := &MachOFile{
 		File:    bin,
-		rawFile: f,
-	}, nil
+		rawFile: r,
+	}
+	file.analyzer = newAnalyzer(file, r)
+	return file, nil
 }
 
-type Function struct {
-	SymFunc *gosym.Func
-	SSAFunc *ssa.Function
-	Inst    []x86asm.Inst
-	Source  []string
-	Callee  []*ssa.Function
+const stabTypeMask = 0xe0
+
+// sectionTypeMask is the mask of the section type in the flags of a section. zerofillSection has no data in the file ( e.g. __bss ).
+const (
+	sectionTypeMask = 0xff
+	zerofillSection = 0x1
+)
+
+// machoSectionNames is the section names for each kind in order of preference.
+// Since Go 1.27, type data and runtime.moduledata have dedicated sections.
+var machoSectionNames = map[sectionKind][]string{
+	textSection:       {"__text"},
+	rodataSection:     {"__go_type", "__rodata"},
+	typelinkSection:   {"__typelink"},
+	gosymtabSection:   {"__gosymtab"},
+	gopclntabSection:  {"__gopclntab"},
+	moduledataSection: {"__go_module", "__noptrdata"},
+	itablinkSection:   {"__itablink"},
 }
 
-type Sym struct {
-	Name string
-	Addr uint64
-	Size int64
-	Code rune
-	Type string
+func (f *MachOFile) section(kind sectionKind) (*section, error) {
+	names := machoSectionNames[kind]
+	var sect *macho.Section
+	for _, name := range names {
+		if sect = f.lookupSection(name); sect != nil {
+			break
+		}
+	}
+	if sect == nil {
+		if kind == gosymtabSection {
+			// __gosymtab is empty and optional since Go 1.3.
+			return &section{}, nil
+		}
+		return nil, fmt.Errorf("failed to find %s section", names[len(names)-1])
+	}
+	data, err := sect.Data()
+	if err != nil {
+		return nil, err
+	}
+	return &section{addr: sect.Addr, data: data}, nil
 }
 
-const stabTypeMask = 0xe0
+// lookupSection returns the section by name.
+// If the binary is PIE ( e.g. darwin/arm64 ), read-only data is placed in the relro segment,
+// so the section in __DATA_CONST is preferred.
+func (f *MachOFile) lookupSection(name string) *macho.Section {
+	for _, s := range f.File.Sections {
+		if s.Seg == "__DATA_CONST" && s.Name == name {
+			return s
+		}
+	}
+	return f.File.Section(name)
+}
 
-type uint64s []uint64
+func (f *MachOFile) readAddr(addr uint64, size int) ([]byte, error) {
+	for _, sect := range f.File.Sections {
+		if sect.Flags&sectionTypeMask == zerofillSection || addr < sect.Addr || sect.Addr+sect.Size < addr+uint64(size) {
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := sect.ReadAt(buf, int64(addr-sect.Addr)); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("failed to find section at %#x", addr)
+}
 
-func (x uint64s) Len() int           { return len(x) }
-func (x uint64s) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
-func (x uint64s) Less(i, j int) bool { return x[i] < x[j] }
+var machoArches = map[macho.Cpu]string{
+	macho.Cpu386:   "386",
+	macho.CpuAmd64: "amd64",
+	macho.CpuArm:   "arm",
+	macho.CpuArm64: "arm64",
+	macho.CpuPpc:   "ppc",
+	macho.CpuPpc64: "ppc64",
+}
+
+func (f *MachOFile) arch() string {
+	if arch, exists := machoArches[f.File.Cpu]; exists {
+		return arch
+	}
+	return f.File.Cpu.String()
+}
 
-type byAddr []Sym
+func (f *MachOFile) byteOrder() binary.ByteOrder {
+	return f.File.ByteOrder
+}
 
-func (x byAddr) Less(i, j int) bool { return x[i].Addr < x[j].Addr }
-func (x byAddr) Len() int           { return len(x) }
-func (x byAddr) Swap(i, j int)      { x[i], x[j] = x[j], x[i] }
+func (f *MachOFile) dwarf() (*dwarf.Data, error) {
+	return f.File.DWARF()
+}
 
 func (f *MachOFile) symbols() ([]Sym, error) {
 	if f.File.Symtab == nil {
@@ -114,179 +178,72 @@ func (f *MachOFile) symbols() ([]Sym, error) {
 	return syms, nil
 }
 
-func (f *MachOFile) load() error {
-	syms, err := f.symbols()
-	if err != nil {
-		return err
-	}
-	f.allSyms = syms
-	funcMap := map[uintptr]*gosym.Func{}
-	symtab, err := f.gosymTable()
-	if err != nil {
-		return err
-	}
-	for _, fn := range symtab.Funcs {
-		fn := fn
-		funcMap[uintptr(fn.Value)] = &fn
-	}
-	f.funcMap = funcMap
-	allTypes, err := f.Types()
-	if err != nil {
-		return err
+// Close closes the underlying file if it was opened by Open.
+func (f *MachOFile) Close() error {
+	if f.closer == nil {
+		return nil
 	}
-	f.allTypes = allTypes
-	return err
+	return f.closer.Close()
 }
 
-func (f *MachOFile) CallGraph() (*callgraph.Graph, error) {
-	funcs, err := f.Funcs()
-	if err != nil {
-		return nil, err
-	}
-	var mainFunc *ssa.Function
-	for _, fn := range funcs {
-		ssaFunc := fn.SSAFunc
-		pkgName := ssaFunc.Package().Pkg.Name()
-		if pkgName == "main" && ssaFunc.Name() == "main" {
-			mainFunc = ssaFunc
-			break
-		}
-	}
-	if mainFunc == nil {
-		return nil, fmt.Errorf("failed to find main function")
-	}
-	graph := callgraph.New(mainFunc)
-	for _, fn := range funcs {
-		ssaFunc := fn.SSAFunc
-		if ssaFunc.Package().String() == "main" && ssaFunc.Name() == "main" {
-			continue
-		}
-		node := graph.CreateNode(ssaFunc)
-		for _, callee := range fn.Callee {
-			calleeNode := graph.CreateNode(callee)
-			callgraph.AddEdge(node, nil, calleeNode)
-		}
-	}
-	return graph, nil
+func (f *MachOFile) Symbols() ([]Sym, error) {
+	return f.symbols()
+}
+
+func (f *MachOFile) CallGraph(opts ...CallGraphOption) (*callgraph.Graph, error) {
+	return f.analyzer.callGraph(opts...)
 }
 
 func (f *MachOFile) Funcs() ([]*Function, error) {
-	symtab, err := f.gosymTable()
-	if err != nil {
-		return nil, err
-	}
-	var loadErr error
-	f.loadOnce.Do(func() {
-		loadErr = f.load()
-	})
-	if loadErr != nil {
-		return nil, loadErr
-	}
-	addr := f.File.Section("__text").Addr
-	textdat, _ := f.File.Section("__text").Data()
-	syms := f.allSyms
-	ssaBuilder := binaryssa.NewBuilder(f.allTypes)
-	lookup := func(addr uint64) (string, uint64) {
-		i := sort.Search(len(syms), func(i int) bool { return addr < syms[i].Addr })
-		if i > 0 {
-			s := syms[i-1]
-			if s.Addr != 0 && s.Addr <= addr && addr < s.Addr+uint64(s.Size) {
-				return s.Name, s.Addr
-			}
-		}
-		return "", 0
-	}
-	funcs := make([]*Function, 0, len(symtab.Funcs))
-	for _, fn := range symtab.Funcs {
-		fn := fn
-		start := fn.Entry - addr
-		end := fn.End - addr
-		mem := textdat[start:end]
-		pc := fn.Entry
-		funcV := &Function{SymFunc: &fn}
-		var pos int
-		for pos < len(mem) {
-			inst, err := x86asm.Decode(mem[pos:], 64)
-			if err != nil {
-				break
-			}
-			switch inst.Op {
-			case x86asm.CALL, x86asm.LCALL:
-				if len(inst.Args) > 0 {
-					rel, ok := inst.Args[0].(x86asm.Rel)
-					if ok {
-						addr := int64(pc) + int64(rel) + int64(inst.Len)
-						fun, found := f.funcMap[uintptr(addr)]
-						if found {
-							funcV.Callee = append(funcV.Callee, ssaBuilder.BuildFunction(*fun))
-						}
-					}
-				}
-			}
-			text := x86asm.GoSyntax(inst, pc, lookup)
-			funcV.Source = append(funcV.Source, text)
-			funcV.Inst = append(funcV.Inst, inst)
-			pos += inst.Len
-			pc += uint64(inst.Len)
-		}
-		funcV.SSAFunc = ssaBuilder.BuildFunction(fn)
-		funcs = append(funcs, funcV)
-	}
-	return funcs, nil
+	return f.analyzer.funcs()
 }
 
 func (f *MachOFile) Types() ([]reflect.Type, error) {
-	sect := f.File.Section("__typelink")
-	typedat, err := sect.Data()
-	if err != nil {
-		return nil, err
-	}
-	typeNum := len(typedat) / 4
-	rosect := f.File.Section("__rodata")
-	rodata, err := rosect.Data()
-	if err != nil {
-		return nil, err
-	}
-	bo := f.File.ByteOrder
-	typeOffsets := []int32{}
-	for i := 0; i < typeNum; i++ {
-		start := 4 * i
-		end := 4 * (i + 1)
-		var v uint32
-		if err := binary.Read(bytes.NewReader(typedat[start:end]), bo, &v); err != nil {
-			return nil, err
-		}
-		typeOffsets = append(typeOffsets, int32(v))
-	}
-	types := make([]reflect.Type, 0, len(typeOffsets))
-	for _, offset := range typeOffsets {
-		typ, err := internalreflect.NewType(rosect.Addr, rodata, bo, offset)
-		if err != nil {
-			return nil, err
-		}
-		t := reflect.Type(typ)
-		if typ.Kind() == reflect.Ptr {
-			t = t.Elem()
-		}
-		types = append(types, t)
-	}
-	return types, nil
+	return f.analyzer.types()
 }
 
-func (f *MachOFile) gosymTable() (*gosym.Table, error) {
-	symdat, err := f.File.Section("__gosymtab").Data()
-	if err != nil {
-		return nil, err
-	}
-	pclndat, err := f.File.Section("__gopclntab").Data()
-	if err != nil {
-		return nil, err
-	}
+// Implementations returns the interface implementation matrix of the binary.
+func (f *MachOFile) Implementations() (*Implementations, error) {
+	return f.analyzer.implementations()
+}
 
-	pcln := gosym.NewLineTable(pclndat, f.File.Section("__text").Addr)
-	tab, err := gosym.NewTable(symdat, pcln)
-	if err != nil {
-		return nil, err
-	}
-	return tab, nil
+// Methods returns all methods of typ including unexported ones with the functions that implement them.
+func (f *MachOFile) Methods(typ reflect.Type) ([]*Method, error) {
+	return f.analyzer.methods(typ)
+}
+
+// Packages returns the packages of the types and the functions in the binary keyed by import path.
+func (f *MachOFile) Packages() (map[string]*types.Package, error) {
+	return f.analyzer.packages()
+}
+
+// Lift lifts the machine code of fn into SSA form.
+func (f *MachOFile) Lift(fn *Function) (*binaryssa.Function, error) {
+	return f.analyzer.lift(fn)
+}
+
+// CFG builds the control-flow graph of fn.
+// The architectures other than amd64, 386 and arm64 are not supported.
+func (f *MachOFile) CFG(fn *Function) (*binarycfg.Graph, error) {
+	return f.analyzer.cfg(fn)
+}
+
+// Metrics returns the complexity metrics of the functions in the binary grouped by package.
+func (f *MachOFile) Metrics() (*Metrics, error) {
+	return f.analyzer.metrics()
+}
+
+// Frames returns the source positions of the instructions of fn.
+func (f *MachOFile) Frames(fn *Function) ([][]Frame, error) {
+	return f.analyzer.frames(fn)
+}
+
+// LineToPCRanges returns the ranges of the instructions generated from the line of the file.
+func (f *MachOFile) LineToPCRanges(file string, line int) ([]*PCRange, error) {
+	return f.analyzer.lineToPCRanges(file, line)
+}
+
+// Itabs returns the itabs in the binary.
+func (f *MachOFile) Itabs() ([]*Itab, error) {
+	return f.analyzer.itabs()
 }
@@ -0,0 +1,105 @@
+package file
+
+import (
+	"debug/macho"
+	"io"
+	"os"
+	"runtime"
+)
+
+// FatMachOFile is a universal binary that has a Mach-O slice for each architecture.
+type FatMachOFile struct {
+	File    *macho.FatFile
+	Slices  []*MachOFile
+	rawFile io.ReaderAt
+	closer  io.Closer
+}
+
+// OpenFatMachOFile opens the named universal binary. The returned FatMachOFile must be closed by Close.
+func OpenFatMachOFile(path string) (*FatMachOFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	fat, err := newFatMachOFile(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	fat.closer = f
+	return fat, nil
+}
+
+// NewFatMachOFile creates FatMachOFile from f. The caller owns f, so Close does not close it.
+func NewFatMachOFile(f *os.File) (*FatMachOFile, error) {
+	return newFatMachOFile(f)
+}
+
+func newFatMachOFile(r io.ReaderAt) (*FatMachOFile, error) {
+	bin, err := macho.NewFatFile(r)
+	if err != nil {
+		return nil, err
+	}
+	slices := make([]*MachOFile, 0, len(bin.Arches))
+	for _, arch := range bin.Arches {
+		slice := &MachOFile{
+			File:    arch.File,
+			rawFile: io.NewSectionReader(r, int64(arch.Offset), int64(arch.Size)),
+		}
+		slice.analyzer = newAnalyzer(slice, slice.rawFile)
+		slices = append(slices, slice)
+	}
+	return &FatMachOFile{
+		File:    bin,
+		Slices:  slices,
+		rawFile: r,
+	}, nil
+}
+
+// Arches returns the CPU types of all slices in the order of Slices.
+func (f *FatMachOFile) Arches() []macho.Cpu {
+	cpus := make([]macho.Cpu, 0, len(f.File.Arches))
+	for _, arch := range f.File.Arches {
+		cpus = append(cpus, arch.Cpu)
+	}
+	return cpus
+}
+
+// Slice returns the slice for cpu.
+func (f *FatMachOFile) Slice(cpu macho.Cpu) (*MachOFile, bool) {
+	for i, arch := range f.File.Arches {
+		if arch.Cpu == cpu {
+			return f.Slices[i], true
+		}
+	}
+	return nil, false
+}
+
+// hostSlice returns the slice for the host architecture. If there is no such slice, returns the first slice.
+func (f *FatMachOFile) hostSlice() *MachOFile {
+	cpu, exists := machoCPUs[runtime.GOARCH]
+	if exists {
+		if slice, found := f.Slice(cpu); found {
+			return slice
+		}
+	}
+	return f.Slices[0]
+}
+
+var machoCPUs = map[string]macho.Cpu{
+	"386":     macho.Cpu386,
+	"amd64":   macho.CpuAmd64,
+	"arm":     macho.CpuArm,
+	"arm64":   macho.CpuArm64,
+	"ppc":     macho.CpuPpc,
+	"ppc64":   macho.CpuPpc64,
+	"ppc64le": macho.CpuPpc64,
+}
+
+// Close closes the underlying file if it was opened by OpenFatMachOFile.
+func (f *FatMachOFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
@@ -0,0 +1,119 @@
+package file_test
+
+import (
+	"bytes"
+	"debug/macho"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+)
+
+// createFatFile creates the universal binary from the Mach-O files like lipo -create.
+func createFatFile(t *testing.T, paths ...string) string {
+	t.Helper()
+	const align = 14
+	var (
+		headers []macho.FatArchHeader
+		images  [][]byte
+	)
+	offset := uint32(1 << align)
+	for _, path := range paths {
+		image, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := macho.NewFile(bytes.NewReader(image))
+		if err != nil {
+			t.Fatal(err)
+		}
+		headers = append(headers, macho.FatArchHeader{
+			Cpu:    f.Cpu,
+			SubCpu: f.SubCpu,
+			Offset: offset,
+			Size:   uint32(len(image)),
+			Align:  align,
+		})
+		images = append(images, image)
+		offset += (uint32(len(image)) + (1<<align - 1)) &^ (1<<align - 1)
+	}
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.BigEndian, [2]uint32{macho.MagicFat, uint32(len(headers))}); err != nil {
+		t.Fatal(err)
+	}
+	if err := binary.Write(&buf, binary.BigEndian, headers); err != nil {
+		t.Fatal(err)
+	}
+	for i, image := range images {
+		buf.Write(make([]byte, int(headers[i].Offset)-buf.Len()))
+		buf.Write(image)
+	}
+	path := filepath.Join(t.TempDir(), "fat")
+	if err := os.WriteFile(path, buf.Bytes(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestFatMachOFile(t *testing.T) {
+	path := createFatFile(t, filepath.Join("testdata", "macho"), filepath.Join("testdata", "macho_arm64"))
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	fatFile, err := file.NewFatMachOFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arches := fatFile.Arches()
+	if len(arches) != 2 || arches[0] != macho.CpuAmd64 || arches[1] != macho.CpuArm64 {
+		t.Fatalf("unexpected arches %v", arches)
+	}
+	for _, cpu := range arches {
+		slice, found := fatFile.Slice(cpu)
+		if !found {
+			t.Fatalf("failed to find slice for %s", cpu)
+		}
+		if slice.File.Cpu != cpu {
+			t.Fatalf("expected %s slice but got %s", cpu, slice.File.Cpu)
+		}
+		types, err := slice.Types()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(types) == 0 {
+			t.Fatalf("failed to get types from %s slice", cpu)
+		}
+	}
+	if _, found := fatFile.Slice(macho.CpuPpc); found {
+		t.Fatal("unexpected ppc slice")
+	}
+
+	fatOpened, err := file.OpenFatMachOFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fatOpened.Slices) != 2 {
+		t.Fatalf("expected 2 slices but got %d", len(fatOpened.Slices))
+	}
+	if err := fatOpened.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// the file opened by OpenFatMachOFile is already closed.
+	if err := fatOpened.Close(); !errors.Is(err, os.ErrClosed) {
+		t.Fatalf("expected os.ErrClosed but got %v", err)
+	}
+
+	opened, err := file.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer opened.Close()
+	if _, ok := opened.(*file.MachOFile); !ok {
+		t.Fatalf("expected *file.MachOFile but got %T", opened)
+	}
+}
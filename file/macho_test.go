@@ -3,10 +3,12 @@ package file_test
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/goccy/binarian/file"
 	"github.com/goccy/binarian/reflect"
+	"golang.org/x/arch/arm64/arm64asm"
 	"golang.org/x/tools/go/callgraph"
 )
 
@@ -55,3 +57,80 @@ func TestMachOFile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestMachOFileARM64(t *testing.T) {
+	path := filepath.Join("testdata", "macho_arm64")
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	machoFile, err := file.NewMachOFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	funcs, err := machoFile.Funcs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mainFunc *file.Function
+	for _, fun := range funcs {
+		if fun.SymFunc.Name == "main.main" {
+			mainFunc = fun
+			break
+		}
+	}
+	if mainFunc == nil {
+		t.Fatal("failed to find main.main")
+	}
+	if len(mainFunc.Inst) == 0 || len(mainFunc.Inst) != len(mainFunc.Source) {
+		t.Fatalf("failed to decode arm64 instructions")
+	}
+	for _, inst := range mainFunc.Inst {
+		if _, ok := inst.Raw.(arm64asm.Inst); !ok {
+			t.Fatalf("expected arm64 instruction but got %T", inst.Raw)
+		}
+	}
+	var unknown bool
+	for _, fun := range funcs {
+		// the bytes that cannot be decoded ( e.g. the zero padding ) are kept as the placeholders.
+		pc := fun.SymFunc.Entry
+		for i, inst := range fun.Inst {
+			if inst.PC != pc {
+				t.Fatalf("expected instruction at %#x but got %#x in %s", pc, inst.PC, fun.SymFunc.Name)
+			}
+			if inst.Op == "?" {
+				unknown = true
+				if fun.Source[i] != "?" {
+					t.Fatalf("unexpected source of the placeholder %q", fun.Source[i])
+				}
+			}
+			pc += uint64(inst.Len)
+		}
+		if pc != fun.SymFunc.End || len(fun.Source) != len(fun.Inst) {
+			t.Fatalf("the instructions of %s do not cover the function", fun.SymFunc.Name)
+		}
+	}
+	if !unknown {
+		t.Fatal("failed to find the placeholder of the bytes that cannot be decoded")
+	}
+	if !strings.HasPrefix(mainFunc.Source[0], "MOVD") {
+		t.Fatalf("unexpected first instruction %q", mainFunc.Source[0])
+	}
+	var foundCallee bool
+	for _, callee := range mainFunc.Callee {
+		if callee.Name() == "f" {
+			foundCallee = true
+		}
+	}
+	if !foundCallee {
+		t.Fatal("failed to find main.f as callee of main.main")
+	}
+
+	graph, err := machoFile.CallGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graph.Nodes) == 0 {
+		t.Fatal("failed to create call graph")
+	}
+}
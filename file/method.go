@@ -0,0 +1,46 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/goccy/binarian/reflect"
+)
+
+// Method is a method of a type in the binary and the functions that implement it.
+type Method struct {
+	reflect.Method
+	// IfaceFunc is the function called through an interface ( Method.Ifn ).
+	// For a method with a value receiver, it is the wrapper that takes a pointer receiver.
+	// It is nil if the linker removed the code.
+	IfaceFunc *Function
+	// Func is the function called by a method call on the type ( Method.Tfn ).
+	// It is nil if the linker removed the code.
+	Func *Function
+}
+
+func (a *analyzer) methods(typ reflect.Type) ([]*Method, error) {
+	if typ == nil {
+		return nil, fmt.Errorf("failed to get methods of nil type")
+	}
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	funcByEntry := make(map[uint64]*Function, len(funcs))
+	for _, fn := range funcs {
+		funcByEntry[fn.SymFunc.Entry] = fn
+	}
+	all := typ.AllMethods()
+	methods := make([]*Method, 0, len(all))
+	for _, m := range all {
+		method := &Method{Method: m}
+		if m.Ifn != 0 {
+			method.IfaceFunc = funcByEntry[uint64(m.Ifn)]
+		}
+		if m.Tfn != 0 {
+			method.Func = funcByEntry[uint64(m.Tfn)]
+		}
+		methods = append(methods, method)
+	}
+	return methods, nil
+}
@@ -0,0 +1,81 @@
+package file_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	internalreflect "github.com/goccy/binarian/internal/reflect"
+)
+
+func TestMethods(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "goversion", "go1.16.15"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Run("value receiver", func(t *testing.T) {
+		methods, err := f.Methods(findType(t, f, "main.Named"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(methods) != 1 {
+			t.Fatalf("unexpected number of methods %d", len(methods))
+		}
+		m := methods[0]
+		if m.Name != "String" || m.PkgPath != "" {
+			t.Fatalf("unexpected method %s ( %q )", m.Name, m.PkgPath)
+		}
+		if m.Func == nil || m.Func.SymFunc.Name != "main.Named.String" || uintptr(m.Func.SymFunc.Entry) != m.Tfn {
+			t.Fatalf("failed to link tfn %#x", m.Tfn)
+		}
+		// the interface call goes through the wrapper with a pointer receiver.
+		if m.IfaceFunc == nil || m.IfaceFunc.SymFunc.Name != "main.(*Named).String" || uintptr(m.IfaceFunc.SymFunc.Entry) != m.Ifn {
+			t.Fatalf("failed to link ifn %#x", m.Ifn)
+		}
+	})
+	t.Run("unexported method", func(t *testing.T) {
+		typ := internalreflect.PtrTo(findType(t, f, "main.Struct"))
+		if typ == nil {
+			t.Fatal("failed to get *main.Struct")
+		}
+		if typ.NumMethod() != 2 {
+			t.Fatalf("unexpected number of exported methods %d", typ.NumMethod())
+		}
+		methods, err := f.Methods(typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(methods) != 3 {
+			t.Fatalf("unexpected number of methods %d", len(methods))
+		}
+		for i, expected := range []struct {
+			name     string
+			pkgPath  string
+			funcName string
+		}{
+			{name: "BaseMethod"},
+			{name: "Method", funcName: "main.(*Struct).Method"},
+			// hidden is inlined into Method, so the linker removes its code.
+			{name: "hidden", pkgPath: "main"},
+		} {
+			m := methods[i]
+			if m.Name != expected.name || m.PkgPath != expected.pkgPath || m.Index != i {
+				t.Fatalf("unexpected method %s ( %q ) at %d", m.Name, m.PkgPath, m.Index)
+			}
+			if expected.funcName == "" {
+				if m.Ifn != 0 || m.Tfn != 0 || m.IfaceFunc != nil || m.Func != nil {
+					t.Fatalf("expected %s to be removed by the linker", m.Name)
+				}
+				continue
+			}
+			if m.Func == nil || m.Func.SymFunc.Name != expected.funcName {
+				t.Fatalf("failed to link %s", m.Name)
+			}
+			if m.IfaceFunc != m.Func {
+				t.Fatalf("expected the same function for ifn and tfn of a pointer receiver")
+			}
+		}
+	})
+}
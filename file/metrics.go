@@ -0,0 +1,139 @@
+package file
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/arch"
+)
+
+// Metrics is the complexity metrics of the control-flow graphs of the functions in a binary grouped by package.
+// The fields are tagged to export them as JSON.
+type Metrics struct {
+	// Packages is sorted by import path.
+	Packages []*PackageMetrics `json:"packages"`
+	// Skipped is the names of the functions whose control-flow graphs cannot be built in the order of their entries.
+	Skipped []string `json:"skipped,omitempty"`
+}
+
+// PackageMetrics is the metrics of the functions in a package.
+type PackageMetrics struct {
+	Path string `json:"path"`
+	// Funcs is sorted by entry address.
+	Funcs []*FuncMetrics `json:"funcs"`
+	// Complexity is the sum of the cyclomatic complexity of the functions.
+	Complexity int `json:"complexity"`
+}
+
+// FuncMetrics is the metrics of the control-flow graph of a function.
+// The blocks and the edges are the ones reachable from the entry. The loop and the conditional jump of the prologue
+// that grows the stack by runtime.morestack are not counted in Loops and Complexity, because the source code does not have them.
+type FuncMetrics struct {
+	Name  string `json:"name"`
+	Entry uint64 `json:"entry"`
+	// Insts is the number of the instructions except the padding.
+	Insts  int `json:"insts"`
+	Blocks int `json:"blocks"`
+	Edges  int `json:"edges"`
+	// Loops is the number of the natural loops and MaxLoopDepth is the nesting depth of the innermost one.
+	Loops        int `json:"loops"`
+	MaxLoopDepth int `json:"max_loop_depth"`
+	// Complexity is the cyclomatic complexity.
+	Complexity int `json:"complexity"`
+}
+
+// metrics computes the metrics of the functions of the packages.
+// The functions generated by the compiler whose symbols have no package path ( e.g. type:.eq.main.T ) are excluded,
+// and the functions whose control-flow graphs cannot be built are skipped.
+// It returns the error that wraps arch.ErrUnsupported if the control-flow graphs of the architecture are not supported.
+func (a *analyzer) metrics() (*Metrics, error) {
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	arc, err := arch.Lookup(a.obj.arch())
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := arc.(arch.Flow); !ok {
+		return nil, fmt.Errorf("failed to compute metrics: %w %s", arch.ErrUnsupported, arc.Name())
+	}
+	morestack := map[uint64]bool{}
+	for _, fn := range funcs {
+		if strings.HasPrefix(fn.SymFunc.Name, "runtime.morestack") {
+			morestack[fn.SymFunc.Entry] = true
+		}
+	}
+	// callsMorestack reports whether b calls runtime.morestack to grow the stack.
+	callsMorestack := func(b *binarycfg.Block) bool {
+		for _, inst := range b.Insts {
+			if target, ok := arc.CallTarget(inst); ok && morestack[target] {
+				return true
+			}
+		}
+		return false
+	}
+	byPath := map[string]*PackageMetrics{}
+	m := &Metrics{}
+	for _, fn := range funcs {
+		path := fn.SymFunc.PackageName()
+		if !isPackagePath(path) || len(fn.Inst) == 0 {
+			continue
+		}
+		g, err := a.cfg(fn)
+		if err != nil {
+			m.Skipped = append(m.Skipped, fn.SymFunc.Name)
+			continue
+		}
+		fm := newFuncMetrics(fn, g, callsMorestack)
+		pkg, exists := byPath[path]
+		if !exists {
+			pkg = &PackageMetrics{Path: path}
+			byPath[path] = pkg
+			m.Packages = append(m.Packages, pkg)
+		}
+		pkg.Funcs = append(pkg.Funcs, fm)
+		pkg.Complexity += fm.Complexity
+	}
+	sort.Slice(m.Packages, func(i, j int) bool {
+		return m.Packages[i].Path < m.Packages[j].Path
+	})
+	for _, pkg := range m.Packages {
+		sort.Slice(pkg.Funcs, func(i, j int) bool {
+			return pkg.Funcs[i].Entry < pkg.Funcs[j].Entry
+		})
+	}
+	return m, nil
+}
+
+func newFuncMetrics(fn *Function, g *binarycfg.Graph, callsMorestack func(b *binarycfg.Block) bool) *FuncMetrics {
+	fm := &FuncMetrics{
+		Name:       fn.SymFunc.Name,
+		Entry:      fn.SymFunc.Entry,
+		Insts:      len(fn.Inst) - len(g.Padding),
+		Complexity: g.Complexity(),
+	}
+	dom := g.Dominators()
+	for _, b := range g.Blocks {
+		// the blocks in the dominator tree are the reachable ones.
+		if b != g.Entry && dom.Idom(b) == nil {
+			continue
+		}
+		fm.Blocks++
+		fm.Edges += len(b.Succs)
+	}
+	for _, loop := range g.Loops() {
+		if loop.Header == g.Entry && len(loop.Latches) == 1 && callsMorestack(loop.Latches[0]) {
+			// the prologue checks the stack bound and jumps back to the entry after growing the stack.
+			fm.Complexity--
+			continue
+		}
+		fm.Loops++
+		if loop.Depth > fm.MaxLoopDepth {
+			fm.MaxLoopDepth = loop.Depth
+		}
+	}
+	return fm
+}
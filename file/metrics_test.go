@@ -0,0 +1,88 @@
+package file_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"github.com/goccy/binarian/internal/arch"
+)
+
+func TestMetrics(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "elf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m, err := f.Metrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Skipped) != 0 {
+		t.Fatalf("unexpected skipped functions %v", m.Skipped)
+	}
+	pkgs := map[string]*file.PackageMetrics{}
+	for _, pkg := range m.Packages {
+		pkgs[pkg.Path] = pkg
+		var complexity int
+		for _, fn := range pkg.Funcs {
+			complexity += fn.Complexity
+		}
+		if complexity != pkg.Complexity {
+			t.Fatalf("expected the complexity of %s to be %d but got %d", pkg.Path, complexity, pkg.Complexity)
+		}
+	}
+	main, exists := pkgs["main"]
+	if !exists {
+		t.Fatal("failed to find the metrics of main")
+	}
+	for _, fn := range main.Funcs {
+		// the functions of main have no branches and no loops except the prologue.
+		if fn.Complexity != 1 || fn.Loops != 0 || fn.Blocks == 0 {
+			t.Fatalf("unexpected metrics of %s: %+v", fn.Name, fn)
+		}
+	}
+	var found bool
+	for _, fn := range pkgs["strconv"].Funcs {
+		if fn.Name != "strconv.formatBits" {
+			continue
+		}
+		found = true
+		if fn.Loops == 0 || fn.MaxLoopDepth != 1 || fn.Complexity <= fn.Loops || fn.Edges < fn.Blocks {
+			t.Fatalf("unexpected metrics of %s: %+v", fn.Name, fn)
+		}
+	}
+	if !found {
+		t.Fatal("failed to find the metrics of strconv.formatBits")
+	}
+}
+
+func TestMetricsGoArch(t *testing.T) {
+	for _, test := range []struct {
+		path        string
+		unsupported bool
+	}{
+		{path: filepath.Join("testdata", "macho_arm64")},
+		{path: filepath.Join("testdata", "goarch", "s390x"), unsupported: true},
+	} {
+		f, err := file.Open(test.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m, err := f.Metrics()
+		f.Close()
+		if test.unsupported {
+			if !errors.Is(err, arch.ErrUnsupported) {
+				t.Fatalf("expected ErrUnsupported from %s but got %v", test.path, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(m.Packages) == 0 || len(m.Skipped) != 0 {
+			t.Fatalf("unexpected metrics of %s: %d packages and skipped %v", test.path, len(m.Packages), m.Skipped)
+		}
+	}
+}
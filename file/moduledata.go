@@ -0,0 +1,173 @@
+package file
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/goccy/binarian/internal/goversion"
+)
+
+// Magic numbers at the head of the pclntab ( runtime.pcHeader ).
+// Only the versions that have a pointer to the pclntab in runtime.moduledata are listed.
+const (
+	go116PclntabMagic = 0xfffffffa
+	go118PclntabMagic = 0xfffffff0
+	go120PclntabMagic = 0xfffffff1
+)
+
+// moduledata is a subset of runtime.moduledata.
+// It is used to find the Go runtime tables in binaries that have no dedicated section for them.
+type moduledata struct {
+	addr        uint64
+	text        uint64
+	etext       uint64
+	types       uint64
+	etypes      uint64
+	typedesclen uint64
+	typelinks   uint64
+	ntypelink   uint64
+	itablinks   uint64
+	nitablink   uint64
+	itaboffset  uint64
+	itabsize    uint64
+	// gofunc is the base address of the funcdata ( go:func.* ) since Go 1.18.
+	gofunc uint64
+}
+
+// moduledataLayout is the field position of runtime.moduledata in pointer-sized words.
+// typedesclen, typelinks, itablinks, itaboffset and gofunc are 0 if the version does not have them.
+// Since Go 1.27, itabs are laid out in the type data ( from types+itaboffset to types+itaboffset+itabsize ) instead of itablinks.
+type moduledataLayout struct {
+	text        int
+	types       int
+	typedesclen int
+	etypes      int
+	itaboffset  int
+	typelinks   int
+	itablinks   int
+	gofunc      int
+}
+
+// size returns the size of the fields used by moduledata in pointer-sized words.
+func (l moduledataLayout) size() int {
+	size := l.itaboffset + 2
+	if l.itablinks != 0 {
+		size = l.itablinks + 2
+	}
+	if l.gofunc >= size {
+		size = l.gofunc + 1
+	}
+	return size
+}
+
+func moduledataLayoutByVersion(v goversion.Version) moduledataLayout {
+	switch {
+	case v.AtLeast(27):
+		return moduledataLayout{text: 22, types: 37, typedesclen: 38, etypes: 39, itaboffset: 40, gofunc: 43}
+	case v.AtLeast(26):
+		return moduledataLayout{text: 22, types: 37, etypes: 38, typelinks: 45, itablinks: 48, gofunc: 40}
+	case v.AtLeast(20):
+		return moduledataLayout{text: 22, types: 37, etypes: 38, typelinks: 44, itablinks: 47, gofunc: 40}
+	case v.AtLeast(18):
+		return moduledataLayout{text: 22, types: 35, etypes: 36, typelinks: 42, itablinks: 45, gofunc: 38}
+	}
+	return moduledataLayout{text: 22, types: 35, etypes: 36, typelinks: 40, itablinks: 43}
+}
+
+// pclntabMagics is the magic numbers of the pclntab since Go 1.16.
+var pclntabMagics = map[uint32]struct{}{
+	go116PclntabMagic: {},
+	go118PclntabMagic: {},
+	go120PclntabMagic: {},
+}
+
+// findPclntab returns the offset of the pclntab header in data, or -1 if not found.
+func findPclntab(data []byte, bo binary.ByteOrder) int {
+	for i := 0; i+8 <= len(data); i += 4 {
+		switch bo.Uint32(data[i:]) {
+		case go116PclntabMagic, go118PclntabMagic, go120PclntabMagic:
+		default:
+			continue
+		}
+		if isPclntabHeader(data[i:], bo) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isPclntabHeader(data []byte, bo binary.ByteOrder) bool {
+	if len(data) < 8 {
+		return false
+	}
+	if _, exists := pclntabMagics[bo.Uint32(data)]; !exists {
+		return false
+	}
+	if data[4] != 0 || data[5] != 0 {
+		return false
+	}
+	switch data[6] { // instruction size quantum
+	case 1, 2, 4:
+	default:
+		return false
+	}
+	switch data[7] { // pointer size
+	case 4, 8:
+	default:
+		return false
+	}
+	return true
+}
+
+// findModuledata searches data for runtime.moduledata whose first field points to the pclntab at pclntabAddr.
+// dataAddr is the virtual address of data[0].
+func findModuledata(data []byte, dataAddr uint64, pclntab []byte, pclntabAddr uint64, bo binary.ByteOrder, version goversion.Version) (*moduledata, error) {
+	if !isPclntabHeader(pclntab, bo) {
+		return nil, fmt.Errorf("failed to find pclntab header")
+	}
+	layout := moduledataLayoutByVersion(version)
+	ptrSize := int(pclntab[7])
+	word := func(v []byte, i int) uint64 {
+		if ptrSize == 4 {
+			return uint64(bo.Uint32(v[i*4:]))
+		}
+		return bo.Uint64(v[i*8:])
+	}
+	size := layout.size() * ptrSize
+	for i := 0; i+size <= len(data); i += ptrSize {
+		v := data[i : i+size]
+		if word(v, 0) != pclntabAddr {
+			continue
+		}
+		md := &moduledata{
+			addr:   dataAddr + uint64(i),
+			text:   word(v, layout.text),
+			etext:  word(v, layout.text+1),
+			types:  word(v, layout.types),
+			etypes: word(v, layout.etypes),
+		}
+		if layout.typedesclen != 0 {
+			md.typedesclen = word(v, layout.typedesclen)
+		}
+		if layout.typelinks != 0 {
+			md.typelinks = word(v, layout.typelinks)
+			md.ntypelink = word(v, layout.typelinks+1)
+		}
+		if layout.itablinks != 0 {
+			md.itablinks = word(v, layout.itablinks)
+			md.nitablink = word(v, layout.itablinks+1)
+		}
+		if layout.itaboffset != 0 {
+			md.itaboffset = word(v, layout.itaboffset)
+			md.itabsize = word(v, layout.itaboffset+1)
+		}
+		if layout.gofunc != 0 {
+			md.gofunc = word(v, layout.gofunc)
+		}
+		if md.text > md.etext || md.types > md.etypes || md.typedesclen > md.etypes-md.types || md.itaboffset+md.itabsize > md.etypes-md.types {
+			continue
+		}
+		return md, nil
+	}
+	return nil, fmt.Errorf("failed to find runtime.moduledata. dataAddr = %x", dataAddr)
+}
@@ -0,0 +1,165 @@
+package file
+
+import (
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// packages groups the types and the functions of the binary into the packages keyed by import path.
+//
+// The scope of each package has the named types converted by the shared Converter and the functions that are not methods.
+// The methods are declared on the named types with the signatures of the method type metadata.
+// Functions other than methods have no type metadata in the binary, so their signatures are built from the DWARF.
+// If the binary has no DWARF or the signature cannot be built from it ( e.g. generic functions ), the signature is empty ( func() ).
+// Func literals, package initializers and the functions generated by the compiler are not declared.
+//
+// The imports of a package are the packages whose functions it calls and whose types its types refer to.
+// The runtime package is not included, because the compiler inserts the calls of it into every package.
+func (a *analyzer) packages() (map[string]*types.Package, error) {
+	a.packagesOnce.Do(func() {
+		a.pkgs, a.pkgsErr = a.loadPackages()
+	})
+	return a.pkgs, a.pkgsErr
+}
+
+func (a *analyzer) loadPackages() (map[string]*types.Package, error) {
+	typs, err := a.types()
+	if err != nil {
+		return nil, err
+	}
+	funcs, err := a.funcs()
+	if err != nil {
+		return nil, err
+	}
+	pkgs := map[string]*types.Package{}
+	imports := map[*types.Package]map[*types.Package]struct{}{}
+	addImport := func(from, to *types.Package) {
+		if from == nil || to == nil || from == to || to.Path() == "runtime" {
+			return
+		}
+		if _, exists := imports[from]; !exists {
+			imports[from] = map[*types.Package]struct{}{}
+		}
+		imports[from][to] = struct{}{}
+	}
+	for _, typ := range typs {
+		if typ.Name() == "" || !isPackagePath(typ.PkgPath()) {
+			continue
+		}
+		named, ok := a.typeConv.TypeFromReflectType(typ).(*types.Named)
+		if !ok {
+			continue
+		}
+		pkg := named.Obj().Pkg()
+		pkgs[pkg.Path()] = pkg
+		refs := map[*types.Package]struct{}{}
+		referredPackages(named.Underlying(), refs, map[types.Type]struct{}{})
+		for i := 0; i < named.NumMethods(); i++ {
+			referredPackages(named.Method(i).Type(), refs, map[types.Type]struct{}{})
+		}
+		for ref := range refs {
+			addImport(pkg, ref)
+		}
+	}
+	pkgByFunc := make(map[*ssa.Function]*types.Package, len(funcs))
+	for _, fn := range funcs {
+		pkgPath := fn.SymFunc.PackageName()
+		if !isPackagePath(pkgPath) {
+			continue
+		}
+		pkg := a.typeConv.Package(pkgPath)
+		pkgs[pkgPath] = pkg
+		pkgByFunc[fn.SSAFunc] = pkg
+		name := strings.TrimPrefix(fn.SymFunc.Name, pkgPath+".")
+		if i := strings.Index(name, "["); i > 0 {
+			// instantiations of a generic function.
+			name = name[:i]
+		}
+		if !token.IsIdentifier(name) || name == "init" {
+			continue
+		}
+		sig := fn.SSAFunc.Signature
+		if alt := a.typeConv.Declare(types.NewFunc(token.NoPos, pkg, name, sig)); alt != nil {
+			continue
+		}
+		refs := map[*types.Package]struct{}{}
+		referredPackages(sig, refs, map[types.Type]struct{}{})
+		for ref := range refs {
+			addImport(pkg, ref)
+		}
+	}
+	for _, fn := range funcs {
+		for _, callee := range fn.Callee {
+			addImport(pkgByFunc[fn.SSAFunc], pkgByFunc[callee])
+		}
+	}
+	for pkg, imported := range imports {
+		list := make([]*types.Package, 0, len(imported))
+		for imp := range imported {
+			list = append(list, imp)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Path() < list[j].Path()
+		})
+		pkg.SetImports(list)
+	}
+	return pkgs, nil
+}
+
+// isPackagePath reports whether path is the import path of a package, not the prefix of the symbols generated by the compiler
+// ( e.g. type:.eq.main.T, go:buildid, go.shape.int, _.goready.func1 ).
+func isPackagePath(path string) bool {
+	if path == "" || path == "_" || strings.Contains(path, ":") {
+		return false
+	}
+	for _, prefix := range []string{"go", "type"} {
+		if path == prefix || strings.HasPrefix(path, prefix+".") {
+			return false
+		}
+	}
+	return true
+}
+
+// referredPackages collects the packages of the named types that typ refers to into refs.
+func referredPackages(typ types.Type, refs map[*types.Package]struct{}, seen map[types.Type]struct{}) {
+	if _, exists := seen[typ]; exists {
+		return
+	}
+	seen[typ] = struct{}{}
+	switch t := typ.(type) {
+	case *types.Named:
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			refs[pkg] = struct{}{}
+		}
+	case *types.Pointer:
+		referredPackages(t.Elem(), refs, seen)
+	case *types.Slice:
+		referredPackages(t.Elem(), refs, seen)
+	case *types.Array:
+		referredPackages(t.Elem(), refs, seen)
+	case *types.Chan:
+		referredPackages(t.Elem(), refs, seen)
+	case *types.Map:
+		referredPackages(t.Key(), refs, seen)
+		referredPackages(t.Elem(), refs, seen)
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			referredPackages(t.Field(i).Type(), refs, seen)
+		}
+	case *types.Tuple:
+		for i := 0; i < t.Len(); i++ {
+			referredPackages(t.At(i).Type(), refs, seen)
+		}
+	case *types.Signature:
+		referredPackages(t.Params(), refs, seen)
+		referredPackages(t.Results(), refs, seen)
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			referredPackages(t.Method(i).Type(), refs, seen)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package file_test
+
+import (
+	"go/types"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+)
+
+func TestPackages(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "elf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	pkgs, err := f.Packages()
+	if err != nil {
+		t.Fatal(err)
+	}
+	main, exists := pkgs["main"]
+	if !exists {
+		t.Fatal("failed to find main package")
+	}
+	if _, exists := pkgs["unsafe"]; exists {
+		t.Fatal("unexpected unsafe package")
+	}
+	iface, ok := main.Scope().Lookup("Iface").(*types.TypeName)
+	if !ok {
+		t.Fatal("failed to find main.Iface")
+	}
+	typ, ok := main.Scope().Lookup("T").(*types.TypeName)
+	if !ok {
+		t.Fatal("failed to find main.T")
+	}
+	if !types.Implements(types.NewPointer(typ.Type()), iface.Type().Underlying().(*types.Interface)) {
+		t.Fatal("expected *main.T to implement main.Iface")
+	}
+	for _, name := range []string{"f", "main"} {
+		if _, ok := main.Scope().Lookup(name).(*types.Func); !ok {
+			t.Fatalf("failed to find function main.%s", name)
+		}
+	}
+	// main.f calls fmt.Println.
+	if imports := main.Imports(); len(imports) != 1 || imports[0] != pkgs["fmt"] {
+		t.Fatalf("unexpected imports %v", imports)
+	}
+	// the types of the other packages are declared in their scopes too.
+	if fmtPkg := pkgs["fmt"]; fmtPkg.Scope().Lookup("Stringer") == nil {
+		t.Fatal("failed to find fmt.Stringer")
+	}
+}
+
+func TestPackagesFuncSignature(t *testing.T) {
+	lookup := func(t *testing.T, path, pkgPath, name string) (*types.Package, *types.Signature) {
+		t.Helper()
+		f, err := file.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		pkgs, err := f.Packages()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pkg, exists := pkgs[pkgPath]
+		if !exists {
+			t.Fatalf("failed to find %s package", pkgPath)
+		}
+		fn, ok := pkg.Scope().Lookup(name).(*types.Func)
+		if !ok {
+			t.Fatalf("failed to find function %s.%s", pkgPath, name)
+		}
+		return pkg, fn.Type().(*types.Signature)
+	}
+	t.Run("dwarf", func(t *testing.T) {
+		path := filepath.Join("testdata", "macho_arm64")
+		main, sig := lookup(t, path, "main", "f")
+		iface, ok := main.Scope().Lookup("Iface").(*types.TypeName)
+		if !ok {
+			t.Fatal("failed to find main.Iface")
+		}
+		if sig.Params().Len() != 1 || sig.Results().Len() != 0 {
+			t.Fatalf("unexpected signature of main.f: %s", sig)
+		}
+		if param := sig.Params().At(0); param.Name() != "v" || !types.Identical(param.Type(), iface.Type()) {
+			t.Fatalf("unexpected parameter of main.f: %s", param)
+		}
+		_, sig = lookup(t, path, "strconv", "FormatInt")
+		if got := sig.String(); got != "func(i int64, base int) string" {
+			t.Fatalf("unexpected signature of strconv.FormatInt: %s", got)
+		}
+	})
+	t.Run("ssa function", func(t *testing.T) {
+		f, err := file.Open(filepath.Join("testdata", "macho_arm64"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		funcs, err := f.Funcs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fn := range funcs {
+			if fn.SymFunc.Name != "main.f" {
+				continue
+			}
+			if got := fn.SSAFunc.Signature.String(); got != "func(v main.Iface)" {
+				t.Fatalf("unexpected signature of main.f: %s", got)
+			}
+			return
+		}
+		t.Fatal("failed to find main.f")
+	})
+	t.Run("register results omitted", func(t *testing.T) {
+		// Go 1.17 omits the results in registers from the DWARF, so the signature is empty.
+		_, sig := lookup(t, filepath.Join("testdata", "elf"), "strconv", "FormatInt")
+		if sig.Params().Len() != 0 || sig.Results().Len() != 0 {
+			t.Fatalf("unexpected signature of strconv.FormatInt: %s", sig)
+		}
+	})
+}
@@ -0,0 +1,389 @@
+package file
+
+import (
+	"debug/dwarf"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"go/types"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/goversion"
+	"github.com/goccy/binarian/reflect"
+	binaryssa "github.com/goccy/binarian/ssa"
+	"golang.org/x/tools/go/callgraph"
+)
+
+// PEFile is a Go binary for Windows.
+// PE has no dedicated sections for the Go runtime tables,
+// so they are found through the runtime.pclntab symbol ( or the magic number of the pclntab ) and runtime.moduledata.
+type PEFile struct {
+	File       *pe.File
+	rawFile    io.ReaderAt
+	closer     io.Closer
+	analyzer   *analyzer
+	tablesOnce sync.Once
+	tables     map[sectionKind]*section
+	tablesErr  error
+}
+
+func NewPEFile(f *os.File) (*PEFile, error) {
+	return newPEFile(f)
+}
+
+func newPEFile(r io.ReaderAt) (*PEFile, error) {
+	bin, err := pe.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	file := &PEFile{
+		File:    bin,
+		rawFile: r,
+	}
+	file.analyzer = newAnalyzer(file, r)
+	return file, nil
+}
+
+func (f *PEFile) imageBase() uint64 {
+	switch oh := f.File.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		return uint64(oh.ImageBase)
+	case *pe.OptionalHeader64:
+		return oh.ImageBase
+	}
+	return 0
+}
+
+// sectionByAddr returns the data of the section that contains addr. The returned data starts at addr.
+func (f *PEFile) sectionByAddr(addr uint64) ([]byte, error) {
+	base := f.imageBase()
+	for _, sect := range f.File.Sections {
+		start := base + uint64(sect.VirtualAddress)
+		end := start + uint64(sect.VirtualSize)
+		if addr < start || end <= addr {
+			continue
+		}
+		data, err := sect.Data()
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(data)) < uint64(sect.VirtualSize) {
+			// the rest of the section is zero filled at runtime.
+			data = append(data, make([]byte, uint64(sect.VirtualSize)-uint64(len(data)))...)
+		}
+		return data[addr-start : end-start], nil
+	}
+	return nil, fmt.Errorf("failed to find section at %x", addr)
+}
+
+func (f *PEFile) readAddr(addr uint64, size int) ([]byte, error) {
+	base := f.imageBase()
+	for _, sect := range f.File.Sections {
+		start := base + uint64(sect.VirtualAddress)
+		if addr < start || start+uint64(sect.Size) < addr+uint64(size) {
+			continue
+		}
+		buf := make([]byte, size)
+		if _, err := sect.ReadAt(buf, int64(addr-start)); err != nil {
+			return nil, err
+		}
+		return buf, nil
+	}
+	return nil, fmt.Errorf("failed to find section at %x", addr)
+}
+
+func (f *PEFile) rangeSection(start, end uint64) (*section, error) {
+	if start > end {
+		return nil, fmt.Errorf("invalid range %x-%x", start, end)
+	}
+	data, err := f.sectionByAddr(start)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < end-start {
+		return nil, fmt.Errorf("range %x-%x is out of section", start, end)
+	}
+	return &section{addr: start, data: data[:end-start]}, nil
+}
+
+func (f *PEFile) symbolAddr(name string) (uint64, bool) {
+	for _, s := range f.File.Symbols {
+		if s.Name != name {
+			continue
+		}
+		if s.SectionNumber <= 0 || len(f.File.Sections) < int(s.SectionNumber) {
+			return 0, false
+		}
+		sect := f.File.Sections[s.SectionNumber-1]
+		return f.imageBase() + uint64(sect.VirtualAddress) + uint64(s.Value), true
+	}
+	return 0, false
+}
+
+func (f *PEFile) findPclntab() (*section, error) {
+	if addr, found := f.symbolAddr("runtime.pclntab"); found {
+		data, err := f.sectionByAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		if end, found := f.symbolAddr("runtime.epclntab"); found && addr <= end && end-addr <= uint64(len(data)) {
+			data = data[:end-addr]
+		}
+		return &section{addr: addr, data: data}, nil
+	}
+	base := f.imageBase()
+	for _, sect := range f.File.Sections {
+		data, err := sect.Data()
+		if err != nil {
+			continue
+		}
+		if offset := findPclntab(data, f.byteOrder()); offset >= 0 {
+			return &section{
+				addr: base + uint64(sect.VirtualAddress) + uint64(offset),
+				data: data[offset:],
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find pclntab")
+}
+
+func (f *PEFile) findModuledata(pclntab *section, version goversion.Version) (*moduledata, error) {
+	if addr, found := f.symbolAddr("runtime.firstmoduledata"); found {
+		data, err := f.sectionByAddr(addr)
+		if err != nil {
+			return nil, err
+		}
+		return findModuledata(data, addr, pclntab.data, pclntab.addr, f.byteOrder(), version)
+	}
+	base := f.imageBase()
+	for _, sect := range f.File.Sections {
+		data, err := sect.Data()
+		if err != nil {
+			continue
+		}
+		md, err := findModuledata(data, base+uint64(sect.VirtualAddress), pclntab.data, pclntab.addr, f.byteOrder(), version)
+		if err == nil {
+			return md, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to find runtime.moduledata")
+}
+
+func (f *PEFile) loadTables() (map[sectionKind]*section, error) {
+	pclntab, err := f.findPclntab()
+	if err != nil {
+		return nil, err
+	}
+	version, err := detectGoVersion(f.rawFile)
+	if err != nil {
+		return nil, err
+	}
+	md, err := f.findModuledata(pclntab, version)
+	if err != nil {
+		return nil, err
+	}
+	text, err := f.rangeSection(md.text, md.etext)
+	if err != nil {
+		return nil, err
+	}
+	// itabs of the binaries built by Go 1.26 or earlier are laid out after the type data in the same section.
+	rodata, err := f.sectionByAddr(md.types)
+	if err != nil {
+		return nil, err
+	}
+	mdData, err := f.sectionByAddr(md.addr)
+	if err != nil {
+		return nil, err
+	}
+	tables := map[sectionKind]*section{
+		textSection:       text,
+		rodataSection:     {addr: md.types, data: rodata},
+		gosymtabSection:   {},
+		gopclntabSection:  pclntab,
+		moduledataSection: {addr: md.addr, data: mdData},
+	}
+	if md.ntypelink != 0 {
+		typelink, err := f.rangeSection(md.typelinks, md.typelinks+4*md.ntypelink)
+		if err != nil {
+			return nil, err
+		}
+		tables[typelinkSection] = typelink
+	}
+	if md.nitablink != 0 {
+		itablink, err := f.rangeSection(md.itablinks, md.itablinks+uint64(pclntab.data[7])*md.nitablink)
+		if err != nil {
+			return nil, err
+		}
+		tables[itablinkSection] = itablink
+	}
+	return tables, nil
+}
+
+func (f *PEFile) section(kind sectionKind) (*section, error) {
+	f.tablesOnce.Do(func() {
+		f.tables, f.tablesErr = f.loadTables()
+	})
+	if f.tablesErr != nil {
+		return nil, f.tablesErr
+	}
+	sect, exists := f.tables[kind]
+	if !exists {
+		return nil, fmt.Errorf("failed to find the table of section kind %d", kind)
+	}
+	return sect, nil
+}
+
+var peArches = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_I386:  "386",
+	pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+}
+
+func (f *PEFile) arch() string {
+	if arch, exists := peArches[f.File.Machine]; exists {
+		return arch
+	}
+	return fmt.Sprintf("unknown(%#x)", f.File.Machine)
+}
+
+func (f *PEFile) byteOrder() binary.ByteOrder {
+	return binary.LittleEndian
+}
+
+func (f *PEFile) dwarf() (*dwarf.Data, error) {
+	return f.File.DWARF()
+}
+
+func (f *PEFile) symbols() ([]Sym, error) {
+	const (
+		undefSection = 0
+		absSection   = -1
+		debugSection = -2
+
+		textCharacteristics = 0x20
+		dataCharacteristics = 0x40
+		bssCharacteristics  = 0x80
+		writeCharacteristic = 0x80000000
+	)
+	base := f.imageBase()
+	var (
+		addrs []uint64
+		syms  []Sym
+	)
+	for _, s := range f.File.Symbols {
+		sym := Sym{Name: s.Name, Addr: uint64(s.Value), Code: '?'}
+		switch s.SectionNumber {
+		case undefSection:
+			sym.Code = 'U'
+		case absSection:
+			sym.Code = 'C'
+		case debugSection:
+			sym.Code = '?'
+		default:
+			if s.SectionNumber < 0 || len(f.File.Sections) < int(s.SectionNumber) {
+				return nil, fmt.Errorf("invalid section number in symbol table")
+			}
+			sect := f.File.Sections[s.SectionNumber-1]
+			ch := sect.Characteristics
+			switch {
+			case ch&textCharacteristics != 0:
+				sym.Code = 'T'
+			case ch&dataCharacteristics != 0:
+				if ch&writeCharacteristic == 0 {
+					sym.Code = 'R'
+				} else {
+					sym.Code = 'D'
+				}
+			case ch&bssCharacteristics != 0:
+				sym.Code = 'B'
+			}
+			sym.Addr += base + uint64(sect.VirtualAddress)
+		}
+		syms = append(syms, sym)
+		addrs = append(addrs, sym.Addr)
+	}
+	sort.Sort(uint64s(addrs))
+	for i := range syms {
+		j := sort.Search(len(addrs), func(x int) bool { return addrs[x] > syms[i].Addr })
+		if j < len(addrs) {
+			syms[i].Size = int64(addrs[j] - syms[i].Addr)
+		}
+	}
+	sort.Sort(byAddr(syms))
+	return syms, nil
+}
+
+// Close closes the underlying file if it was opened by Open.
+func (f *PEFile) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
+
+func (f *PEFile) Symbols() ([]Sym, error) {
+	return f.symbols()
+}
+
+func (f *PEFile) CallGraph(opts ...CallGraphOption) (*callgraph.Graph, error) {
+	return f.analyzer.callGraph(opts...)
+}
+
+func (f *PEFile) Funcs() ([]*Function, error) {
+	return f.analyzer.funcs()
+}
+
+func (f *PEFile) Types() ([]reflect.Type, error) {
+	return f.analyzer.types()
+}
+
+// Implementations returns the interface implementation matrix of the binary.
+func (f *PEFile) Implementations() (*Implementations, error) {
+	return f.analyzer.implementations()
+}
+
+// Methods returns all methods of typ including unexported ones with the functions that implement them.
+func (f *PEFile) Methods(typ reflect.Type) ([]*Method, error) {
+	return f.analyzer.methods(typ)
+}
+
+// Packages returns the packages of the types and the functions in the binary keyed by import path.
+func (f *PEFile) Packages() (map[string]*types.Package, error) {
+	return f.analyzer.packages()
+}
+
+// Lift lifts the machine code of fn into SSA form.
+func (f *PEFile) Lift(fn *Function) (*binaryssa.Function, error) {
+	return f.analyzer.lift(fn)
+}
+
+// CFG builds the control-flow graph of fn.
+// The architectures other than amd64, 386 and arm64 are not supported.
+func (f *PEFile) CFG(fn *Function) (*binarycfg.Graph, error) {
+	return f.analyzer.cfg(fn)
+}
+
+// Metrics returns the complexity metrics of the functions in the binary grouped by package.
+func (f *PEFile) Metrics() (*Metrics, error) {
+	return f.analyzer.metrics()
+}
+
+// Frames returns the source positions of the instructions of fn.
+func (f *PEFile) Frames(fn *Function) ([][]Frame, error) {
+	return f.analyzer.frames(fn)
+}
+
+// LineToPCRanges returns the ranges of the instructions generated from the line of the file.
+func (f *PEFile) LineToPCRanges(file string, line int) ([]*PCRange, error) {
+	return f.analyzer.lineToPCRanges(file, line)
+}
+
+// Itabs returns the itabs in the binary.
+func (f *PEFile) Itabs() ([]*Itab, error) {
+	return f.analyzer.itabs()
+}
@@ -0,0 +1,66 @@
+package file_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"github.com/goccy/binarian/reflect"
+	"golang.org/x/tools/go/callgraph"
+)
+
+func TestPEFile(t *testing.T) {
+	path := filepath.Join("testdata", "pe")
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peFile, err := file.NewPEFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types, err := peFile.Types()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range types {
+		if typ.Kind() != reflect.Interface {
+			continue
+		}
+		for i := 0; i < typ.NumMethod(); i++ {
+			mtd := typ.Method(i)
+			foundMtd, found := typ.MethodByName(mtd.Name)
+			if !found || foundMtd.Name != mtd.Name {
+				t.Fatalf("failed to get method by name %s", mtd.Name)
+			}
+		}
+	}
+	funcs, err := peFile.Funcs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var foundMain bool
+	for _, fun := range funcs {
+		if fun.SymFunc.Name == "main.main" {
+			foundMain = true
+			if len(fun.Callee) == 0 {
+				t.Fatal("failed to find callee of main.main")
+			}
+		}
+	}
+	if !foundMain {
+		t.Fatal("failed to find main.main")
+	}
+
+	graph, err := peFile.CallGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := callgraph.GraphVisitEdges(graph, func(edge *callgraph.Edge) error {
+		t.Logf("%s => %s\n", edge.Caller, edge.Callee)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
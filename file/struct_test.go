@@ -0,0 +1,73 @@
+package file_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	binaryreflect "github.com/goccy/binarian/reflect"
+	"github.com/goccy/binarian/types"
+)
+
+func TestStructField(t *testing.T) {
+	// the embedded flag of struct fields is encoded in the field offset until Go 1.18.
+	for _, version := range []string{"go1.16.15", "go1.27.1"} {
+		version := version
+		t.Run(version, func(t *testing.T) {
+			f, err := file.Open(filepath.Join("testdata", "goversion", version))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			typ := findType(t, f, "main.Struct")
+			field, found := typ.FieldByName("ID")
+			if !found {
+				t.Fatal("failed to find promoted field ID")
+			}
+			if !reflect.DeepEqual(field.Index, []int{0, 0}) {
+				t.Fatalf("unexpected index %v", field.Index)
+			}
+			if field.Tag.Get("json") != "id" {
+				t.Fatalf("unexpected tag %q", field.Tag)
+			}
+			if byIndex := typ.FieldByIndex(field.Index); byIndex.Name != "ID" {
+				t.Fatalf("unexpected field %s", byIndex.Name)
+			}
+			field, found = typ.FieldByNameFunc(func(name string) bool { return strings.HasPrefix(name, "Val") })
+			if !found || field.Name != "Values" || field.Offset != 24 || field.Type.String() != "[]int" {
+				t.Fatalf("unexpected field %+v", field)
+			}
+			if _, found := typ.FieldByName("Missing"); found {
+				t.Fatal("found unknown field")
+			}
+			base := typ.Field(0)
+			if !base.Anonymous || base.Name != "Base" {
+				t.Fatalf("unexpected embedded field %+v", base)
+			}
+			st, err := types.StructTypeFromReflectType(typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := st.Underlying().String(); !strings.Contains(got, `Name string "json:\"name\""`) {
+				t.Fatalf("unexpected struct type %s", got)
+			}
+		})
+	}
+}
+
+func findType(t *testing.T, f file.File, name string) binaryreflect.Type {
+	t.Helper()
+	types, err := f.Types()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, typ := range types {
+		if typ.String() == name {
+			return typ
+		}
+	}
+	t.Fatalf("failed to find %s", name)
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import "sync"
+
+//go:noinline
+func worker(wg *sync.WaitGroup, ch chan<- int, v int) {
+	defer wg.Done()
+	ch <- v
+}
+
+//go:noinline
+func apply(f func(int) int, v int) int {
+	return f(v)
+}
+
+func main() {
+	var wg sync.WaitGroup
+	ch := make(chan int, 1)
+	wg.Add(1)
+	go worker(&wg, ch, 1)
+	defer func() {
+		println("done")
+	}()
+	n := <-ch
+	println(apply(func(v int) int { return v + n }, 2))
+	wg.Wait()
+}
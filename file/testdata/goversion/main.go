@@ -0,0 +1,51 @@
+package main
+
+type Named int
+
+func (n Named) String() string {
+	return "named"
+}
+
+type Base struct {
+	ID int `json:"id"`
+}
+
+func (b *Base) BaseMethod() int {
+	return b.ID
+}
+
+type Struct struct {
+	Base
+	Name    string            `json:"name"`
+	Values  []int             `json:"values,omitempty"`
+	Table   map[string]*Named `json:"table"`
+	Ch      chan<- int
+	Fn      func(int, ...string) (bool, error)
+	Array   [4]byte
+	private float64
+}
+
+func (s *Struct) Method(v int) string {
+	s.hidden()
+	return s.Name
+}
+
+func (s *Struct) hidden() {
+	s.private++
+}
+
+type Iface interface {
+	Method(int) string
+}
+
+type Stringer interface {
+	String() string
+}
+
+var sink interface{}
+
+func main() {
+	var v interface{} = &Struct{}
+	sink = Named(1)
+	println(v.(Iface).Method(1), sink.(Stringer).String())
+}
@@ -0,0 +1,115 @@
+package file_test
+
+import (
+	gotypes "go/types"
+	"path/filepath"
+	"testing"
+
+	"github.com/goccy/binarian/file"
+	"github.com/goccy/binarian/types"
+)
+
+func TestTypeFromReflectTypeNamed(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "goversion", "go1.16.15"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	t.Run("method set", func(t *testing.T) {
+		named, ok := types.TypeFromReflectType(findType(t, f, "main.Struct")).(*gotypes.Named)
+		if !ok {
+			t.Fatal("expected main.Struct to be converted to *types.Named")
+		}
+		if pkg := named.Obj().Pkg(); pkg == nil || pkg.Path() != "main" || pkg.Name() != "main" {
+			t.Fatalf("unexpected package %v", pkg)
+		}
+		if pkg := named.Obj().Pkg(); pkg.Scope().Lookup("Struct") != named.Obj() {
+			t.Fatal("failed to find main.Struct in the package scope")
+		}
+		if named.NumMethods() != 2 {
+			t.Fatalf("unexpected number of declared methods %d", named.NumMethods())
+		}
+		mset := gotypes.NewMethodSet(gotypes.NewPointer(named))
+		for _, name := range []string{"Method", "hidden"} {
+			if mset.Lookup(named.Obj().Pkg(), name) == nil {
+				t.Fatalf("failed to find method %s of *main.Struct", name)
+			}
+		}
+		// the linker removes the type of BaseMethod with its code, so the signature is unknown.
+		if mset.Lookup(named.Obj().Pkg(), "BaseMethod") != nil {
+			t.Fatal("unexpected method BaseMethod")
+		}
+		if gotypes.NewMethodSet(named).Len() != 0 {
+			t.Fatal("expected main.Struct to have no methods with the value receiver")
+		}
+		iface, ok := types.TypeFromReflectType(findType(t, f, "main.Iface")).Underlying().(*gotypes.Interface)
+		if !ok {
+			t.Fatal("expected main.Iface to be an interface type")
+		}
+		if !gotypes.Implements(gotypes.NewPointer(named), iface) {
+			t.Fatal("expected *main.Struct to implement main.Iface")
+		}
+		if gotypes.Implements(named, iface) {
+			t.Fatal("expected main.Struct not to implement main.Iface")
+		}
+	})
+	t.Run("value receiver", func(t *testing.T) {
+		typ := types.TypeFromReflectType(findType(t, f, "main.Named"))
+		if typ.String() != "main.Named" || typ.Underlying() != gotypes.Typ[gotypes.Int] {
+			t.Fatalf("unexpected type %s ( %s )", typ, typ.Underlying())
+		}
+		stringer := types.TypeFromReflectType(findType(t, f, "main.Stringer")).Underlying().(*gotypes.Interface)
+		if !gotypes.Implements(typ, stringer) {
+			t.Fatal("expected main.Named to implement main.Stringer")
+		}
+	})
+}
+
+func TestTypeFromReflectTypeRecursive(t *testing.T) {
+	f, err := file.Open(filepath.Join("testdata", "elf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	conv := types.NewConverter()
+	// type poolChainElt struct { poolDequeue; next, prev *poolChainElt }
+	elt, ok := conv.TypeFromReflectType(findType(t, f, "sync.poolChainElt")).(*gotypes.Named)
+	if !ok {
+		t.Fatal("expected sync.poolChainElt to be converted to *types.Named")
+	}
+	next := fieldByName(t, elt, "next")
+	if ptr, ok := next.Type().(*gotypes.Pointer); !ok || ptr.Elem() != elt {
+		t.Fatalf("unexpected type of next field %s", next.Type())
+	}
+	if next.Pkg() != elt.Obj().Pkg() || next.Pkg().Path() != "sync" {
+		t.Fatalf("unexpected package of next field %v", next.Pkg())
+	}
+	// the converted types are shared in the Converter.
+	chain, ok := conv.TypeFromReflectType(findType(t, f, "sync.poolChain")).(*gotypes.Named)
+	if !ok {
+		t.Fatal("expected sync.poolChain to be converted to *types.Named")
+	}
+	if chain.Obj().Pkg() != elt.Obj().Pkg() {
+		t.Fatal("expected the same package for the types in sync")
+	}
+	if ptr, ok := fieldByName(t, chain, "head").Type().(*gotypes.Pointer); !ok || ptr.Elem() != elt {
+		t.Fatal("expected the same sync.poolChainElt for the head field of sync.poolChain")
+	}
+}
+
+func fieldByName(t *testing.T, named *gotypes.Named, name string) *gotypes.Var {
+	t.Helper()
+	st, ok := named.Underlying().(*gotypes.Struct)
+	if !ok {
+		t.Fatalf("expected %s to be a struct type", named)
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if st.Field(i).Name() == name {
+			return st.Field(i)
+		}
+	}
+	t.Fatalf("failed to find field %s of %s", name, named)
+	return nil
+}
@@ -1,12 +1,8 @@
 module github.com/goccy/binarian
 
-go 1.17
+go 1.18
 
 require (
-	github.com/k0kubun/pp v3.0.1+incompatible // indirect
-	github.com/mattn/go-colorable v0.1.12 // indirect
-	github.com/mattn/go-isatty v0.0.14 // indirect
-	golang.org/x/arch v0.0.0-20210923205945-b76863e36670 // indirect
-	golang.org/x/sys v0.0.0-20211019181941-9d821ace8654 // indirect
-	golang.org/x/tools v0.1.8 // indirect
+	golang.org/x/arch v0.14.0
+	golang.org/x/tools v0.1.8
 )
@@ -0,0 +1,139 @@
+// Package arch provides the architecture dependent part of machine code analysis.
+package arch
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Inst is a decoded machine instruction independent of the architecture.
+type Inst struct {
+	// PC is the address of the instruction.
+	PC uint64
+	// Len is the length of the encoded instruction in bytes.
+	Len int
+	// Op is the mnemonic of the instruction.
+	Op string
+	// Raw is the instruction decoded by golang.org/x/arch ( e.g. x86asm.Inst ).
+	Raw interface{}
+}
+
+// UnknownOp is the Op of the bytes that cannot be decoded. go tool objdump prints them as "?" too.
+const UnknownOp = "?"
+
+// SymLookup returns the name and base address of the symbol that contains addr.
+type SymLookup func(addr uint64) (string, uint64)
+
+// Arch decodes and interprets the machine code of an architecture.
+type Arch interface {
+	// Name returns the architecture name in GOARCH style.
+	Name() string
+	// MinInstLen returns the minimum length of an instruction.
+	// It is used to skip the bytes that cannot be decoded.
+	MinInstLen() int
+	// Decode decodes the instruction at the head of mem that is placed at pc.
+	Decode(mem []byte, pc uint64) (*Inst, error)
+	// Unknown returns the placeholder of the size bytes at pc that cannot be decoded.
+	// Its Raw is the zero instruction of the architecture, so the other methods treat it as an instruction that does nothing.
+	Unknown(pc uint64, size int) *Inst
+	// CallTarget returns the callee address if inst is a direct call.
+	CallTarget(inst *Inst) (uint64, bool)
+	// BranchTarget returns the destination address if inst is a direct ( conditional or unconditional ) jump.
+	BranchTarget(inst *Inst) (uint64, bool)
+	// GoSyntax returns inst in Go assembler syntax.
+	GoSyntax(inst *Inst, lookup SymLookup) string
+}
+
+// Indirect is implemented by the architectures that can trace the callee address of indirect calls.
+// Go loads the callee of an interface method call from the method table of the itab, and the callee of a closure call from the funcval.
+type Indirect interface {
+	// IndirectCallee returns the memory that the callee address of the indirect call insts[i] is loaded from:
+	// the index of the load instruction, the base register and the displacement.
+	// It scans backward from insts[i] through the register moves until the register of the call is loaded.
+	IndirectCallee(insts []*Inst, i int) (load int, base string, disp int64, ok bool)
+	// AddrRef returns the absolute address that insts[i] materializes in a register ( e.g. LEAQ sym(SB), AX ).
+	AddrRef(insts []*Inst, i int) (uint64, bool)
+	// TraceBack returns the location of the value in loc after insts[i] before insts[i] is executed.
+	// It returns the address instead if insts[i] materializes it in loc ( see AddrRef ),
+	// and false if the value cannot be traced ( e.g. it is computed or clobbered by a call ).
+	TraceBack(insts []*Inst, i int, loc Loc) (prev Loc, addr uint64, ok bool)
+	// CallerLoc returns the location that the caller writes before the call instruction for the value in loc at the entry of the callee.
+	// It returns false if loc cannot hold an argument at the entry ( e.g. the stack slot below the stack pointer ).
+	CallerLoc(loc Loc) (Loc, bool)
+}
+
+// Loc is the location of a value traced by Indirect: a register or a stack slot.
+type Loc struct {
+	// Reg is the name of the 64-bit register that contains the register ( e.g. RAX for EAX ). It is empty for the stack slot.
+	Reg string
+	// Off is the offset of the stack slot from the stack pointer.
+	Off int64
+}
+
+// FlowKind is how the control leaves an instruction.
+type FlowKind int
+
+const (
+	// FlowNext continues to the next instruction.
+	FlowNext FlowKind = iota
+	// FlowJump jumps to the BranchTarget.
+	FlowJump
+	// FlowCondJump jumps to the BranchTarget or continues to the next instruction.
+	FlowCondJump
+	// FlowIndirectJump jumps to the address in a register or the memory.
+	FlowIndirectJump
+	// FlowReturn returns to the caller.
+	FlowReturn
+	// FlowTrap stops the execution ( e.g. UD2, INT3, HLT ).
+	FlowTrap
+)
+
+// ReadMemory reads size bytes at the virtual address addr.
+type ReadMemory func(addr uint64, size int) ([]byte, error)
+
+// Flow is implemented by the architectures that can build the control-flow graphs of the functions.
+type Flow interface {
+	// Flow returns how the control leaves inst.
+	Flow(inst *Inst) FlowKind
+	// JumpTable returns the destinations of the indirect jump insts[i] if it jumps through the jump table of a switch statement.
+	// The compiler emits the jump tables since Go 1.19, and bounds the index before the jump.
+	JumpTable(insts []*Inst, i int, read ReadMemory) ([]uint64, bool)
+	// Padding reports whether inst fills the gap between the functions ( e.g. INT3 ).
+	Padding(inst *Inst) bool
+}
+
+var arches = map[string]Arch{
+	"386":     &x86{name: "386", mode: 32},
+	"amd64":   &x86{name: "amd64", mode: 64},
+	"arm":     &arm{},
+	"arm64":   &arm64{},
+	"loong64": &loong64{},
+	"ppc64":   newPPC64("ppc64"),
+	"ppc64le": newPPC64("ppc64le"),
+	"riscv64": &riscv64{},
+	"s390x":   &s390x{},
+}
+
+// ErrUnsupported is returned by Lookup for the architectures that have no decoder.
+var ErrUnsupported = errors.New("unsupported architecture")
+
+// noDecoderArches is the architectures that Go supports but golang.org/x/arch has no decoder for.
+var noDecoderArches = map[string]struct{}{
+	"mips":     {},
+	"mipsle":   {},
+	"mips64":   {},
+	"mips64le": {},
+}
+
+// Lookup returns the Arch for the architecture name in GOARCH style.
+// The error wraps ErrUnsupported if the architecture has no decoder ( e.g. mips ).
+func Lookup(name string) (Arch, error) {
+	if _, exists := noDecoderArches[name]; exists {
+		return nil, fmt.Errorf("%w %s: golang.org/x/arch has no decoder for it", ErrUnsupported, name)
+	}
+	arch, exists := arches[name]
+	if !exists {
+		return nil, fmt.Errorf("%w %s", ErrUnsupported, name)
+	}
+	return arch, nil
+}
@@ -0,0 +1,364 @@
+package arch_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/goccy/binarian/internal/arch"
+)
+
+func TestCallTarget(t *testing.T) {
+	const pc = 0x1000
+	for _, test := range []struct {
+		arch   string
+		code   []byte
+		len    int
+		target uint64
+	}{
+		{arch: "amd64", code: []byte{0xe8, 0x10, 0x00, 0x00, 0x00}, len: 5, target: pc + 5 + 0x10},
+		{arch: "386", code: []byte{0xe8, 0x10, 0x00, 0x00, 0x00}, len: 5, target: pc + 5 + 0x10},
+		{arch: "arm64", code: []byte{0x04, 0x00, 0x00, 0x94}, len: 4, target: pc + 16},
+		{arch: "arm", code: []byte{0x02, 0x00, 0x00, 0xeb}, len: 4, target: pc + 8 + 8},
+		{arch: "ppc64le", code: []byte{0x11, 0x00, 0x00, 0x48}, len: 4, target: pc + 16},
+		{arch: "ppc64", code: []byte{0x48, 0x00, 0x00, 0x11}, len: 4, target: pc + 16},
+		{arch: "riscv64", code: []byte{0xef, 0x00, 0x00, 0x01}, len: 4, target: pc + 16},
+		{arch: "s390x", code: []byte{0xc0, 0xe5, 0x00, 0x00, 0x00, 0x08}, len: 6, target: pc + 16},
+		{arch: "loong64", code: []byte{0x00, 0x10, 0x00, 0x54}, len: 4, target: pc + 16},
+	} {
+		test := test
+		t.Run(test.arch, func(t *testing.T) {
+			a, err := arch.Lookup(test.arch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			inst, err := a.Decode(test.code, pc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if inst.Len != test.len {
+				t.Fatalf("expected length %d but got %d", test.len, inst.Len)
+			}
+			target, ok := a.CallTarget(inst)
+			if !ok {
+				t.Fatalf("failed to get call target of %s", a.GoSyntax(inst, nil))
+			}
+			if target != test.target {
+				t.Fatalf("expected call target %#x but got %#x", test.target, target)
+			}
+			if _, ok := a.BranchTarget(inst); ok {
+				t.Fatal("call must not be a branch")
+			}
+			if a.GoSyntax(inst, func(uint64) (string, uint64) { return "", 0 }) == "" {
+				t.Fatal("failed to format instruction")
+			}
+		})
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	const pc = 0x1000
+	for _, name := range []string{"amd64", "386", "arm64", "arm", "ppc64le", "ppc64", "riscv64", "s390x", "loong64"} {
+		a, err := arch.Lookup(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inst := a.Unknown(pc, a.MinInstLen())
+		if inst.PC != pc || inst.Len != a.MinInstLen() || inst.Op != arch.UnknownOp {
+			t.Fatalf("unexpected placeholder of %s: %+v", name, inst)
+		}
+		if _, ok := a.CallTarget(inst); ok {
+			t.Fatalf("the placeholder of %s must not be a call", name)
+		}
+		if _, ok := a.BranchTarget(inst); ok {
+			t.Fatalf("the placeholder of %s must not be a branch", name)
+		}
+		if flow, ok := a.(arch.Flow); ok && flow.Flow(inst) != arch.FlowNext {
+			t.Fatalf("the placeholder of %s must continue to the next instruction", name)
+		}
+	}
+}
+
+func TestLookupUnknownArch(t *testing.T) {
+	for _, name := range []string{"unknown", "mips", "mipsle", "mips64", "mips64le"} {
+		if _, err := arch.Lookup(name); !errors.Is(err, arch.ErrUnsupported) {
+			t.Fatalf("expected ErrUnsupported for %s but got %v", name, err)
+		}
+	}
+}
+
+func decodeAll(t *testing.T, a arch.Arch, code []byte, pc uint64) []*arch.Inst {
+	t.Helper()
+	var insts []*arch.Inst
+	for pos := 0; pos < len(code); {
+		inst, err := a.Decode(code[pos:], pc+uint64(pos))
+		if err != nil {
+			t.Fatal(err)
+		}
+		insts = append(insts, inst)
+		pos += inst.Len
+	}
+	return insts
+}
+
+func TestIndirectCallee(t *testing.T) {
+	const pc = 0x1000
+	for _, test := range []struct {
+		arch string
+		code []byte
+		base string
+		disp int64
+	}{
+		{
+			arch: "amd64",
+			code: []byte{
+				0x48, 0x8b, 0x48, 0x18, // MOVQ 0x18(AX), CX
+				0x48, 0x89, 0xd8, // MOVQ BX, AX
+				0xff, 0xd1, // CALL CX
+			},
+			base: "RAX",
+			disp: 0x18,
+		},
+		{
+			arch: "arm64",
+			code: []byte{
+				0x00, 0x0c, 0x40, 0xf9, // MOVD 24(R0), R0
+				0x41, 0x01, 0x80, 0xd2, // MOVD $10, R1
+				0x00, 0x00, 0x3f, 0xd6, // CALL (R0)
+			},
+			base: "X0",
+			disp: 24,
+		},
+	} {
+		test := test
+		t.Run(test.arch, func(t *testing.T) {
+			a, err := arch.Lookup(test.arch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			indirect, ok := a.(arch.Indirect)
+			if !ok {
+				t.Fatalf("%s does not implement arch.Indirect", test.arch)
+			}
+			insts := decodeAll(t, a, test.code, pc)
+			load, base, disp, ok := indirect.IndirectCallee(insts, len(insts)-1)
+			if !ok {
+				t.Fatal("failed to trace the callee")
+			}
+			if load != 0 || base != test.base || disp != test.disp {
+				t.Fatalf("expected %d(%s) at 0 but got %d(%s) at %d", test.disp, test.base, disp, base, load)
+			}
+			if _, _, _, ok := indirect.IndirectCallee(insts, 0); ok {
+				t.Fatal("load must not be an indirect call")
+			}
+		})
+	}
+}
+
+func TestTraceBack(t *testing.T) {
+	for _, test := range []struct {
+		arch string
+		pc   uint64
+		code []byte
+		loc  arch.Loc
+		// addr is materialized by code[at].
+		addr uint64
+		at   int
+	}{
+		{
+			arch: "amd64",
+			pc:   0x1000,
+			code: []byte{
+				0x48, 0x8d, 0x05, 0x00, 0x01, 0x00, 0x00, // LEAQ 0x100(IP), AX
+				0x48, 0x83, 0xec, 0x48, // SUBQ $0x48, SP
+				0x48, 0x89, 0x44, 0x24, 0x50, // MOVQ AX, 0x50(SP)
+				0xe8, 0x00, 0x00, 0x00, 0x00, // CALL
+				0x48, 0x8b, 0x44, 0x24, 0x50, // MOVQ 0x50(SP), AX
+			},
+			loc:  arch.Loc{Reg: "RAX"},
+			addr: 0x1107,
+			at:   0,
+		},
+		{
+			arch: "arm64",
+			pc:   0x10000,
+			code: []byte{
+				0x03, 0x00, 0x00, 0x90, // ADRP 0(PC), R3
+				0x63, 0x00, 0x04, 0x91, // ADD $0x100, R3, R3
+				0xe3, 0x07, 0x00, 0xf9, // MOVD R3, 8(RSP)
+				0xfe, 0x0f, 0x19, 0xf8, // MOVD.W R30, -112(RSP)
+				0xe0, 0x3f, 0x40, 0xf9, // MOVD 120(RSP), R0
+			},
+			loc:  arch.Loc{Reg: "X0"},
+			addr: 0x10100,
+			at:   1,
+		},
+	} {
+		test := test
+		t.Run(test.arch, func(t *testing.T) {
+			a, err := arch.Lookup(test.arch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			indirect := a.(arch.Indirect)
+			insts := decodeAll(t, a, test.code, test.pc)
+			loc := test.loc
+			for i := len(insts) - 1; i >= 0; i-- {
+				prev, addr, ok := indirect.TraceBack(insts, i, loc)
+				if !ok {
+					t.Fatalf("failed to trace %+v over %s", loc, a.GoSyntax(insts[i], nil))
+				}
+				if addr != 0 {
+					if addr != test.addr || i != test.at {
+						t.Fatalf("expected %#x but got %#x at %d", test.addr, addr, i)
+					}
+					return
+				}
+				loc = prev
+			}
+			t.Fatal("failed to find the address")
+		})
+	}
+}
+
+func TestTraceBackCall(t *testing.T) {
+	a, err := arch.Lookup("amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	insts := decodeAll(t, a, []byte{
+		0xe8, 0x00, 0x00, 0x00, 0x00, // CALL
+	}, 0x1000)
+	indirect := a.(arch.Indirect)
+	// the callee clobbers the registers but not the frame of the caller.
+	if _, _, ok := indirect.TraceBack(insts, 0, arch.Loc{Reg: "RAX"}); ok {
+		t.Fatal("the register must be clobbered by the call")
+	}
+	if loc, _, ok := indirect.TraceBack(insts, 0, arch.Loc{Off: 8}); !ok || loc != (arch.Loc{Off: 8}) {
+		t.Fatalf("unexpected location %+v of the stack slot", loc)
+	}
+	// the call pushes the return address.
+	if loc, ok := indirect.CallerLoc(arch.Loc{Off: 8}); !ok || loc != (arch.Loc{Off: 0}) {
+		t.Fatalf("unexpected location %+v in the caller", loc)
+	}
+	if _, ok := indirect.CallerLoc(arch.Loc{Off: -8}); ok {
+		t.Fatal("the stack slot below the stack pointer must not be an argument")
+	}
+}
+
+func TestAddrRef(t *testing.T) {
+	const pc = 0x10000
+	for _, test := range []struct {
+		arch string
+		code []byte
+		addr uint64
+	}{
+		// LEAQ 0x10(IP), AX
+		{arch: "amd64", code: []byte{0x48, 0x8d, 0x05, 0x10, 0x00, 0x00, 0x00}, addr: pc + 7 + 0x10},
+		// LEAL 0x2000, AX
+		{arch: "386", code: []byte{0x8d, 0x05, 0x00, 0x20, 0x00, 0x00}, addr: 0x2000},
+		// ADRP 0(PC), R1; ADD $0x10, R1, R1
+		{arch: "arm64", code: []byte{0x01, 0x00, 0x00, 0x90, 0x21, 0x40, 0x00, 0x91}, addr: pc + 0x10},
+	} {
+		test := test
+		t.Run(test.arch, func(t *testing.T) {
+			a, err := arch.Lookup(test.arch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			insts := decodeAll(t, a, test.code, pc)
+			addr, ok := a.(arch.Indirect).AddrRef(insts, len(insts)-1)
+			if !ok {
+				t.Fatal("failed to get the referred address")
+			}
+			if addr != test.addr {
+				t.Fatalf("expected %#x but got %#x", test.addr, addr)
+			}
+		})
+	}
+}
+
+func TestJumpTable(t *testing.T) {
+	const pc = 0x1000
+	a, err := arch.Lookup("amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	insts := decodeAll(t, a, []byte{
+		0x48, 0x83, 0xf9, 0x02, // CMPQ CX, $0x2
+		0x77, 0x0a, // JA default
+		0x48, 0x8d, 0x15, 0x00, 0x01, 0x00, 0x00, // LEAQ 0x100(IP), DX
+		0xff, 0x24, 0xca, // JMP 0(DX)(CX*8)
+		0xcc, // INT $0x3
+	}, pc)
+	flow := a.(arch.Flow)
+	for i, expected := range []arch.FlowKind{arch.FlowNext, arch.FlowCondJump, arch.FlowNext, arch.FlowIndirectJump, arch.FlowTrap} {
+		if kind := flow.Flow(insts[i]); kind != expected {
+			t.Fatalf("expected flow %d of %s but got %d", expected, a.GoSyntax(insts[i], nil), kind)
+		}
+	}
+	if !flow.Padding(insts[4]) || flow.Padding(insts[0]) {
+		t.Fatal("only INT3 must be padding")
+	}
+	const table = pc + 13 + 0x100
+	targets, ok := flow.JumpTable(insts, 3, func(addr uint64, size int) ([]byte, error) {
+		if addr != table || size != 3*8 {
+			t.Fatalf("unexpected read of %d bytes at %#x", size, addr)
+		}
+		return []byte{
+			0x10, 0x10, 0, 0, 0, 0, 0, 0,
+			0x20, 0x10, 0, 0, 0, 0, 0, 0,
+			0x10, 0x10, 0, 0, 0, 0, 0, 0,
+		}, nil
+	})
+	if !ok {
+		t.Fatal("failed to resolve the jump table")
+	}
+	if len(targets) != 3 || targets[0] != 0x1010 || targets[1] != 0x1020 || targets[2] != 0x1010 {
+		t.Fatalf("unexpected targets %#x", targets)
+	}
+}
+
+func TestJumpTableARM64(t *testing.T) {
+	const pc = 0x10000
+	a, err := arch.Lookup("arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	insts := decodeAll(t, a, []byte{
+		0x5f, 0x08, 0x00, 0xf1, // CMP $2, R2
+		0x88, 0x01, 0x00, 0x54, // BHI default
+		0x03, 0x00, 0x00, 0x90, // ADRP 0(PC), R3
+		0x63, 0x00, 0x04, 0x91, // ADD $0x100, R3, R3
+		0x7b, 0x78, 0x62, 0xf8, // MOVD (R3)(R2<<3), R27
+		0x60, 0x03, 0x1f, 0xd6, // JMP (R27)
+		0x00, 0x00, 0x20, 0xd4, // BRK
+	}, pc)
+	// the zero padding cannot be decoded.
+	insts = append(insts, a.Unknown(pc+28, 4))
+	flow := a.(arch.Flow)
+	for i, expected := range []arch.FlowKind{arch.FlowNext, arch.FlowCondJump, arch.FlowNext, arch.FlowNext, arch.FlowNext, arch.FlowIndirectJump, arch.FlowTrap, arch.FlowNext} {
+		if kind := flow.Flow(insts[i]); kind != expected {
+			t.Fatalf("expected flow %d of %s but got %d", expected, a.GoSyntax(insts[i], nil), kind)
+		}
+	}
+	if !flow.Padding(insts[7]) || flow.Padding(insts[6]) {
+		t.Fatal("the bytes that cannot be decoded must be padding")
+	}
+	const table = pc + 0x100
+	targets, ok := flow.JumpTable(insts, 5, func(addr uint64, size int) ([]byte, error) {
+		if addr != table || size != 3*8 {
+			t.Fatalf("unexpected read of %d bytes at %#x", size, addr)
+		}
+		return []byte{
+			0x10, 0x00, 0x01, 0, 0, 0, 0, 0,
+			0x20, 0x00, 0x01, 0, 0, 0, 0, 0,
+			0x10, 0x00, 0x01, 0, 0, 0, 0, 0,
+		}, nil
+	})
+	if !ok {
+		t.Fatal("failed to resolve the jump table")
+	}
+	if len(targets) != 3 || targets[0] != 0x10010 || targets[1] != 0x10020 || targets[2] != 0x10010 {
+		t.Fatalf("unexpected targets %#x", targets)
+	}
+}
@@ -0,0 +1,55 @@
+package arch
+
+import (
+	"strings"
+
+	"golang.org/x/arch/arm/armasm"
+)
+
+type arm struct{}
+
+func (a *arm) Name() string { return "arm" }
+
+func (a *arm) MinInstLen() int { return 4 }
+
+func (a *arm) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := armasm.Decode(mem, armasm.ModeARM)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: inst.Len, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *arm) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: armasm.Inst{}}
+}
+
+func (a *arm) CallTarget(inst *Inst) (uint64, bool) {
+	// BL, BLX and their conditional variants ( e.g. BL.EQ ).
+	if !strings.HasPrefix(inst.Op, "BL") {
+		return 0, false
+	}
+	return a.pcRelTarget(inst)
+}
+
+func (a *arm) BranchTarget(inst *Inst) (uint64, bool) {
+	// B and its conditional variants ( e.g. B.EQ ).
+	if inst.Op != "B" && !strings.HasPrefix(inst.Op, "B.") {
+		return 0, false
+	}
+	return a.pcRelTarget(inst)
+}
+
+func (a *arm) pcRelTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(armasm.Inst)
+	rel, ok := raw.Args[0].(armasm.PCRel)
+	if !ok {
+		return 0, false
+	}
+	// PC reads as the address of the current instruction plus 8 in ARM state.
+	return uint64(uint32(inst.PC) + 8 + uint32(rel)), true
+}
+
+func (a *arm) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return armasm.GoSyntax(inst.Raw.(armasm.Inst), inst.PC, lookup, nil)
+}
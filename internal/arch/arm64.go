@@ -0,0 +1,475 @@
+package arch
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+
+	"golang.org/x/arch/arm64/arm64asm"
+)
+
+type arm64 struct{}
+
+func (a *arm64) Name() string { return "arm64" }
+
+func (a *arm64) MinInstLen() int { return 4 }
+
+func (a *arm64) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := arm64asm.Decode(mem)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: 4, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *arm64) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: arm64asm.Inst{}}
+}
+
+func (a *arm64) CallTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(arm64asm.Inst)
+	if raw.Op != arm64asm.BL {
+		return 0, false
+	}
+	return a.pcRelTarget(inst, raw)
+}
+
+func (a *arm64) BranchTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(arm64asm.Inst)
+	switch raw.Op {
+	case arm64asm.B, arm64asm.CBZ, arm64asm.CBNZ, arm64asm.TBZ, arm64asm.TBNZ:
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *arm64) pcRelTarget(inst *Inst, raw arm64asm.Inst) (uint64, bool) {
+	for _, arg := range raw.Args {
+		if rel, ok := arg.(arm64asm.PCRel); ok {
+			return uint64(int64(inst.PC) + int64(rel)), true
+		}
+	}
+	return 0, false
+}
+
+func (a *arm64) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return arm64asm.GoSyntax(inst.Raw.(arm64asm.Inst), inst.PC, lookup, nil)
+}
+
+func (a *arm64) IndirectCallee(insts []*Inst, i int) (int, string, int64, bool) {
+	call := insts[i].Raw.(arm64asm.Inst)
+	if call.Op != arm64asm.BLR {
+		return 0, "", 0, false
+	}
+	reg, ok := call.Args[0].(arm64asm.Reg)
+	if !ok {
+		return 0, "", 0, false
+	}
+	for j := i - 1; j >= 0 && j >= i-maxIndirectScan; j-- {
+		raw := insts[j].Raw.(arm64asm.Inst)
+		if _, ok := a.BranchTarget(insts[j]); ok || raw.Op == arm64asm.BL || raw.Op == arm64asm.BLR || raw.Op == arm64asm.RET {
+			return 0, "", 0, false
+		}
+		switch raw.Op {
+		case arm64asm.STR, arm64asm.STUR, arm64asm.STP, arm64asm.CMP, arm64asm.TST:
+			continue
+		}
+		if dst, ok := raw.Args[0].(arm64asm.Reg); !ok || dst != reg {
+			continue
+		}
+		if src, ok := raw.Args[1].(arm64asm.Reg); ok && raw.Op == arm64asm.MOV {
+			reg = src
+			continue
+		}
+		mem, ok := raw.Args[1].(arm64asm.MemImmediate)
+		if (raw.Op != arm64asm.LDR && raw.Op != arm64asm.LDUR) || !ok || mem.Mode != arm64asm.AddrOffset {
+			return 0, "", 0, false
+		}
+		disp, ok := memImmediateOffset(mem)
+		if !ok {
+			return 0, "", 0, false
+		}
+		return j, mem.Base.String(), disp, true
+	}
+	return 0, "", 0, false
+}
+
+// memImmediateOffset returns the offset of [Xn,#imm]. arm64asm does not export the offset, so it is parsed from the text.
+func memImmediateOffset(mem arm64asm.MemImmediate) (int64, bool) {
+	s := mem.String()
+	i := strings.Index(s, ",#")
+	if i < 0 {
+		return 0, true // [Xn]
+	}
+	// [Xn,#imm], [Xn,#imm]! or [Xn],#imm
+	v, err := strconv.ParseInt(strings.TrimRight(s[i+2:], "]!"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// AddrRef resolves the pair of ADRP Xd, page and ADD Xd, Xd, #offset.
+func (a *arm64) AddrRef(insts []*Inst, i int) (uint64, bool) {
+	if i == 0 {
+		return 0, false
+	}
+	add := insts[i].Raw.(arm64asm.Inst)
+	adrp := insts[i-1].Raw.(arm64asm.Inst)
+	if add.Op != arm64asm.ADD || adrp.Op != arm64asm.ADRP {
+		return 0, false
+	}
+	dst, ok := adrp.Args[0].(arm64asm.Reg)
+	if !ok {
+		return 0, false
+	}
+	if src, ok := add.Args[1].(arm64asm.RegSP); !ok || arm64asm.Reg(src) != dst {
+		return 0, false
+	}
+	page, ok := adrp.Args[1].(arm64asm.PCRel)
+	if !ok {
+		return 0, false
+	}
+	imm, ok := add.Args[2].(arm64asm.ImmShift)
+	if !ok {
+		return 0, false
+	}
+	// ImmShift does not export the immediate, so it is parsed from the text ( #0x8e0 ).
+	offset, err := strconv.ParseUint(strings.TrimPrefix(imm.String(), "#"), 0, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint64(int64(insts[i-1].PC&^0xfff)+int64(page)) + offset, true
+}
+
+func (a *arm64) TraceBack(insts []*Inst, i int, loc Loc) (Loc, uint64, bool) {
+	inst := insts[i]
+	if inst.Op == UnknownOp {
+		return Loc{}, 0, false
+	}
+	raw := inst.Raw.(arm64asm.Inst)
+	if _, ok := a.BranchTarget(inst); ok {
+		return loc, 0, true
+	}
+	switch raw.Op {
+	case arm64asm.BL, arm64asm.BLR:
+		// the callee does not preserve the registers, but it does not write the frame of the caller.
+		return loc, 0, loc.Reg == ""
+	case arm64asm.CMP, arm64asm.CMN, arm64asm.TST, arm64asm.CCMP, arm64asm.CCMN, arm64asm.NOP:
+		return loc, 0, true
+	case arm64asm.STR, arm64asm.STUR, arm64asm.STRB, arm64asm.STURB, arm64asm.STRH, arm64asm.STURH, arm64asm.STP:
+		return a.traceStore(raw, loc)
+	case arm64asm.LDR, arm64asm.LDUR, arm64asm.LDP:
+		return a.traceLoad(raw, loc)
+	}
+	dst, ok := arm64Reg(raw.Args[0])
+	if !ok {
+		return loc, 0, true
+	}
+	// arm64asm names X31 SP only in the operands that can be the stack pointer.
+	if sp, ok := raw.Args[0].(arm64asm.RegSP); ok && arm64asm.Reg(sp) == arm64asm.SP {
+		if loc.Reg != "" {
+			return loc, 0, true
+		}
+		// SUB $n, RSP, RSP allocates a large frame.
+		src, ok := raw.Args[1].(arm64asm.RegSP)
+		if !ok || arm64asm.Reg(src) != arm64asm.SP {
+			return Loc{}, 0, false
+		}
+		imm, ok := raw.Args[2].(arm64asm.ImmShift)
+		if !ok {
+			return Loc{}, 0, false
+		}
+		// ImmShift does not export the immediate, so it is parsed from the text ( #0x70 ).
+		v, err := strconv.ParseInt(strings.TrimPrefix(imm.String(), "#"), 0, 64)
+		if err != nil {
+			return Loc{}, 0, false
+		}
+		switch raw.Op {
+		case arm64asm.SUB:
+			return Loc{Off: loc.Off - v}, 0, true
+		case arm64asm.ADD:
+			return Loc{Off: loc.Off + v}, 0, true
+		}
+		return Loc{}, 0, false
+	}
+	if loc.Reg == "" || dst.String() != loc.Reg {
+		return loc, 0, true
+	}
+	if addr, ok := a.AddrRef(insts, i); ok {
+		return Loc{}, addr, true
+	}
+	if src, ok := raw.Args[1].(arm64asm.Reg); ok && raw.Op == arm64asm.MOV && arm64RegFamily(src) != arm64asm.XZR {
+		return Loc{Reg: arm64RegFamily(src).String()}, 0, true
+	}
+	return Loc{}, 0, false
+}
+
+// CallerLoc returns loc as is because the call does not push the return address.
+// The stack slot at the stack pointer is reserved for the return address that the callee saves.
+func (a *arm64) CallerLoc(loc Loc) (Loc, bool) {
+	if loc.Reg == arm64asm.SP.String() || loc.Reg == "" && loc.Off < 8 {
+		return Loc{}, false
+	}
+	return loc, true
+}
+
+// traceStore traces loc back over the store ( STR Rt, [Xn,#imm] or STP Rt1, Rt2, [Xn,#imm] ).
+func (a *arm64) traceStore(raw arm64asm.Inst, loc Loc) (Loc, uint64, bool) {
+	srcs := []arm64asm.Arg{raw.Args[0]}
+	if raw.Op == arm64asm.STP {
+		srcs = append(srcs, raw.Args[1])
+	}
+	mem, ok := raw.Args[len(srcs)].(arm64asm.MemImmediate)
+	if !ok {
+		if ext, ok := raw.Args[len(srcs)].(arm64asm.MemExtend); ok && arm64asm.Reg(ext.Base) == arm64asm.SP && loc.Reg == "" {
+			// the offset is in the index register.
+			return Loc{}, 0, false
+		}
+		return loc, 0, true
+	}
+	base := arm64asm.Reg(mem.Base)
+	if base != arm64asm.SP {
+		if loc.Reg == base.String() && mem.Mode != arm64asm.AddrOffset {
+			return Loc{}, 0, false
+		}
+		return loc, 0, true
+	}
+	if loc.Reg != "" {
+		return loc, 0, true
+	}
+	imm, ok := memImmediateOffset(mem)
+	if !ok {
+		return Loc{}, 0, false
+	}
+	// addr is the offset of the stored memory from the stack pointer after the store, and delta is the change of the stack pointer.
+	var addr, delta int64
+	switch mem.Mode {
+	case arm64asm.AddrOffset:
+		addr = imm
+	case arm64asm.AddrPreIndex:
+		delta = imm
+	case arm64asm.AddrPostIndex:
+		addr, delta = -imm, imm
+	default:
+		return Loc{}, 0, false
+	}
+	var size int64
+	switch raw.Op {
+	case arm64asm.STRB, arm64asm.STURB:
+		size = 1
+	case arm64asm.STRH, arm64asm.STURH:
+		size = 2
+	default:
+		src, _ := srcs[0].(arm64asm.Reg)
+		size = arm64RegSize(src)
+	}
+	for j, src := range srcs {
+		off := addr + int64(j)*size
+		if loc.Off+8 <= off || off+size <= loc.Off {
+			continue
+		}
+		if reg, ok := src.(arm64asm.Reg); ok && off == loc.Off && size == 8 && reg != arm64asm.XZR {
+			return Loc{Reg: reg.String()}, 0, true
+		}
+		return Loc{}, 0, false
+	}
+	return Loc{Off: loc.Off + delta}, 0, true
+}
+
+// traceLoad traces loc back over the load ( LDR Rt, [Xn,#imm] or LDP Rt1, Rt2, [Xn,#imm] ).
+func (a *arm64) traceLoad(raw arm64asm.Inst, loc Loc) (Loc, uint64, bool) {
+	dsts := []arm64asm.Arg{raw.Args[0]}
+	if raw.Op == arm64asm.LDP {
+		dsts = append(dsts, raw.Args[1])
+	}
+	mem, isImm := raw.Args[len(dsts)].(arm64asm.MemImmediate)
+	var (
+		imm   int64
+		immOK bool
+	)
+	if isImm {
+		imm, immOK = memImmediateOffset(mem)
+	}
+	writeback := isImm && mem.Mode != arm64asm.AddrOffset
+	fromSP := isImm && arm64asm.Reg(mem.Base) == arm64asm.SP
+	if loc.Reg == "" {
+		if !fromSP || !writeback {
+			return loc, 0, true
+		}
+		if !immOK {
+			return Loc{}, 0, false
+		}
+		return Loc{Off: loc.Off + imm}, 0, true
+	}
+	if writeback && arm64asm.Reg(mem.Base).String() == loc.Reg {
+		return Loc{}, 0, false
+	}
+	for j, dst := range dsts {
+		reg, ok := dst.(arm64asm.Reg)
+		if !ok || arm64RegFamily(reg).String() != loc.Reg {
+			continue
+		}
+		if !fromSP || !immOK || reg != arm64RegFamily(reg) {
+			return Loc{}, 0, false
+		}
+		// addr is the offset of the loaded memory from the stack pointer before the load.
+		addr := imm
+		if mem.Mode == arm64asm.AddrPostIndex {
+			addr = 0
+		}
+		return Loc{Off: addr + int64(j)*8}, 0, true
+	}
+	return loc, 0, true
+}
+
+// arm64RegSize returns the size of the register r in bytes.
+func arm64RegSize(r arm64asm.Reg) int64 {
+	switch {
+	case arm64asm.W0 <= r && r <= arm64asm.WZR:
+		return 4
+	case arm64asm.X0 <= r && r <= arm64asm.XZR:
+		return 8
+	case arm64asm.B0 <= r && r <= arm64asm.B31:
+		return 1
+	case arm64asm.H0 <= r && r <= arm64asm.H31:
+		return 2
+	case arm64asm.S0 <= r && r <= arm64asm.S31:
+		return 4
+	case arm64asm.D0 <= r && r <= arm64asm.D31:
+		return 8
+	}
+	return 16
+}
+
+func (a *arm64) Flow(inst *Inst) FlowKind {
+	raw := inst.Raw.(arm64asm.Inst)
+	switch raw.Op {
+	case arm64asm.RET:
+		return FlowReturn
+	case arm64asm.BRK, arm64asm.HLT:
+		return FlowTrap
+	case arm64asm.BR:
+		return FlowIndirectJump
+	case arm64asm.B:
+		// B.cond has the condition as the first argument.
+		if cond, ok := raw.Args[0].(arm64asm.Cond); ok && cond.Value < 14 {
+			return FlowCondJump
+		}
+		return FlowJump
+	case arm64asm.CBZ, arm64asm.CBNZ, arm64asm.TBZ, arm64asm.TBNZ:
+		return FlowCondJump
+	}
+	return FlowNext
+}
+
+// JumpTable resolves the jump table that the compiler emits for arm64 as follows.
+//
+//	CMP $23, R2
+//	BHI default
+//	ADRP table(PC), R3
+//	ADD $offset, R3, R3
+//	MOVD (R3)(R2<<3), R27
+//	JMP (R27)
+//
+// The entries of the table are the absolute addresses of the cases, and the comparison decides their number.
+func (a *arm64) JumpTable(insts []*Inst, i int, read ReadMemory) ([]uint64, bool) {
+	br := insts[i].Raw.(arm64asm.Inst)
+	if br.Op != arm64asm.BR {
+		return nil, false
+	}
+	target, ok := br.Args[0].(arm64asm.Reg)
+	if !ok {
+		return nil, false
+	}
+	var (
+		mem      *arm64asm.MemExtend
+		table, n uint64
+	)
+	for j := i - 1; j >= 0 && j >= i-maxIndirectScan && (mem == nil || table == 0 || n == 0); j-- {
+		raw := insts[j].Raw.(arm64asm.Inst)
+		switch raw.Op {
+		case arm64asm.BL, arm64asm.BLR, arm64asm.BR, arm64asm.RET:
+			return nil, false
+		case arm64asm.B:
+			if a.Flow(insts[j]) == FlowJump {
+				return nil, false
+			}
+			continue
+		case arm64asm.STR, arm64asm.STRB, arm64asm.STRH, arm64asm.STUR, arm64asm.STP:
+			// the first argument is the source.
+			continue
+		}
+		dst, ok := arm64Reg(raw.Args[0])
+		if !ok {
+			continue
+		}
+		switch {
+		case mem == nil:
+			if dst != arm64RegFamily(target) {
+				continue
+			}
+			m, ok := raw.Args[1].(arm64asm.MemExtend)
+			if raw.Op != arm64asm.LDR || !ok || m.Amount != 3 {
+				return nil, false
+			}
+			mem = &m
+		case dst == arm64RegFamily(arm64asm.Reg(mem.Base)) && table == 0:
+			addr, ok := a.AddrRef(insts, j)
+			if !ok {
+				return nil, false
+			}
+			table = addr
+		case dst == arm64RegFamily(mem.Index) && n == 0:
+			if raw.Op != arm64asm.CMP {
+				// the index is computed without a bound.
+				return nil, false
+			}
+			if imm, ok := raw.Args[1].(arm64asm.ImmShift); ok {
+				// ImmShift does not export the immediate, so it is parsed from the text ( #0x17 ).
+				v, err := strconv.ParseUint(strings.TrimPrefix(imm.String(), "#"), 0, 64)
+				if err != nil {
+					return nil, false
+				}
+				n = v + 1
+			}
+		}
+	}
+	if mem == nil || table == 0 || n == 0 || n > maxJumpTableLen {
+		return nil, false
+	}
+	data, err := read(table, int(n)*8)
+	if err != nil {
+		return nil, false
+	}
+	targets := make([]uint64, n)
+	for k := range targets {
+		targets[k] = binary.LittleEndian.Uint64(data[k*8:])
+	}
+	return targets, true
+}
+
+// Padding reports whether inst is NOP or the bytes that cannot be decoded.
+// The compiler places NOP after the calls of the functions that never return, and fills the gaps between the functions with zeros.
+func (a *arm64) Padding(inst *Inst) bool {
+	return inst.Op == UnknownOp || inst.Raw.(arm64asm.Inst).Op == arm64asm.NOP
+}
+
+// arm64Reg returns the 64-bit register of arg if it is a general purpose register.
+func arm64Reg(arg arm64asm.Arg) (arm64asm.Reg, bool) {
+	switch r := arg.(type) {
+	case arm64asm.Reg:
+		return arm64RegFamily(r), true
+	case arm64asm.RegSP:
+		return arm64RegFamily(arm64asm.Reg(r)), true
+	}
+	return 0, false
+}
+
+// arm64RegFamily returns the 64-bit register that contains the general purpose register r ( e.g. X2 for W2 ).
+func arm64RegFamily(r arm64asm.Reg) arm64asm.Reg {
+	if arm64asm.W0 <= r && r <= arm64asm.WZR {
+		return r - arm64asm.W0 + arm64asm.X0
+	}
+	return r
+}
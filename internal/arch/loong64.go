@@ -0,0 +1,61 @@
+package arch
+
+import (
+	"golang.org/x/arch/loong64/loong64asm"
+)
+
+type loong64 struct{}
+
+func (a *loong64) Name() string { return "loong64" }
+
+func (a *loong64) MinInstLen() int { return 4 }
+
+func (a *loong64) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := loong64asm.Decode(mem)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: 4, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *loong64) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: loong64asm.Inst{}}
+}
+
+func (a *loong64) CallTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(loong64asm.Inst)
+	if raw.Op != loong64asm.BL {
+		return 0, false
+	}
+	return a.pcRelTarget(inst, raw)
+}
+
+func (a *loong64) BranchTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(loong64asm.Inst)
+	switch raw.Op {
+	case loong64asm.B,
+		loong64asm.BEQ, loong64asm.BNE, loong64asm.BLT, loong64asm.BGE, loong64asm.BLTU, loong64asm.BGEU,
+		loong64asm.BEQZ, loong64asm.BNEZ, loong64asm.BCEQZ, loong64asm.BCNEZ:
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *loong64) pcRelTarget(inst *Inst, raw loong64asm.Inst) (uint64, bool) {
+	for _, arg := range raw.Args {
+		if off, ok := arg.(loong64asm.OffsetSimm); ok {
+			return uint64(int64(inst.PC) + a.offset(off)), true
+		}
+	}
+	return 0, false
+}
+
+// offset sign-extends the byte offset of off because the decoder doesn't always fill the upper bits.
+func (a *loong64) offset(off loong64asm.OffsetSimm) int64 {
+	shift := 32 - (off.Width + 2)
+	return int64(int32(uint32(off.Imm)<<shift) >> shift)
+}
+
+func (a *loong64) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return loong64asm.GoSyntax(inst.Raw.(loong64asm.Inst), inst.PC, lookup)
+}
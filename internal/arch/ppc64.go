@@ -0,0 +1,65 @@
+package arch
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/arch/ppc64/ppc64asm"
+)
+
+type ppc64 struct {
+	name string
+	bo   binary.ByteOrder
+}
+
+func newPPC64(name string) *ppc64 {
+	if name == "ppc64le" {
+		return &ppc64{name: name, bo: binary.LittleEndian}
+	}
+	return &ppc64{name: name, bo: binary.BigEndian}
+}
+
+func (a *ppc64) Name() string { return a.name }
+
+func (a *ppc64) MinInstLen() int { return 4 }
+
+func (a *ppc64) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := ppc64asm.Decode(mem, a.bo)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: inst.Len, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *ppc64) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: ppc64asm.Inst{}}
+}
+
+func (a *ppc64) CallTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(ppc64asm.Inst)
+	if raw.Op != ppc64asm.BL {
+		return 0, false
+	}
+	return a.pcRelTarget(inst, raw)
+}
+
+func (a *ppc64) BranchTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(ppc64asm.Inst)
+	switch raw.Op {
+	case ppc64asm.B, ppc64asm.BC:
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *ppc64) pcRelTarget(inst *Inst, raw ppc64asm.Inst) (uint64, bool) {
+	for _, arg := range raw.Args {
+		if rel, ok := arg.(ppc64asm.PCRel); ok {
+			return uint64(int64(inst.PC) + int64(rel)), true
+		}
+	}
+	return 0, false
+}
+
+func (a *ppc64) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return ppc64asm.GoSyntax(inst.Raw.(ppc64asm.Inst), inst.PC, lookup)
+}
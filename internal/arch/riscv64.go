@@ -0,0 +1,65 @@
+package arch
+
+import (
+	"golang.org/x/arch/riscv64/riscv64asm"
+)
+
+type riscv64 struct{}
+
+func (a *riscv64) Name() string { return "riscv64" }
+
+// MinInstLen returns 2 because of the compressed instructions.
+func (a *riscv64) MinInstLen() int { return 2 }
+
+func (a *riscv64) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := riscv64asm.Decode(mem)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: inst.Len, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *riscv64) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: riscv64asm.Inst{}}
+}
+
+func (a *riscv64) CallTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(riscv64asm.Inst)
+	switch raw.Op {
+	case riscv64asm.JAL:
+		// JAL that links to X0 is a jump.
+		if rd, ok := raw.Args[0].(riscv64asm.Reg); ok && rd == riscv64asm.X0 {
+			return 0, false
+		}
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *riscv64) BranchTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(riscv64asm.Inst)
+	switch raw.Op {
+	case riscv64asm.JAL:
+		if rd, ok := raw.Args[0].(riscv64asm.Reg); ok && rd == riscv64asm.X0 {
+			return a.pcRelTarget(inst, raw)
+		}
+	case riscv64asm.C_J,
+		riscv64asm.BEQ, riscv64asm.BNE, riscv64asm.BLT, riscv64asm.BGE, riscv64asm.BLTU, riscv64asm.BGEU,
+		riscv64asm.C_BEQZ, riscv64asm.C_BNEZ:
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *riscv64) pcRelTarget(inst *Inst, raw riscv64asm.Inst) (uint64, bool) {
+	for _, arg := range raw.Args {
+		if imm, ok := arg.(riscv64asm.Simm); ok {
+			return uint64(int64(inst.PC) + int64(imm.Imm)), true
+		}
+	}
+	return 0, false
+}
+
+func (a *riscv64) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return riscv64asm.GoSyntax(inst.Raw.(riscv64asm.Inst), inst.PC, lookup, nil)
+}
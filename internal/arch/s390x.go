@@ -0,0 +1,58 @@
+package arch
+
+import (
+	"golang.org/x/arch/s390x/s390xasm"
+)
+
+type s390x struct{}
+
+func (a *s390x) Name() string { return "s390x" }
+
+func (a *s390x) MinInstLen() int { return 2 }
+
+func (a *s390x) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := s390xasm.Decode(mem)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: inst.Len, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *s390x) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: s390xasm.Inst{}}
+}
+
+func (a *s390x) CallTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(s390xasm.Inst)
+	switch raw.Op {
+	case s390xasm.BRAS, s390xasm.BRASL:
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *s390x) BranchTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(s390xasm.Inst)
+	switch raw.Op {
+	case s390xasm.BRC, s390xasm.BRCL:
+		return a.pcRelTarget(inst, raw)
+	}
+	return 0, false
+}
+
+// pcRelTarget returns the target of the relative-immediate operand. The operand is the number of halfwords.
+func (a *s390x) pcRelTarget(inst *Inst, raw s390xasm.Inst) (uint64, bool) {
+	for _, arg := range raw.Args {
+		switch rel := arg.(type) {
+		case s390xasm.RegIm16:
+			return uint64(int64(inst.PC) + 2*int64(int16(rel))), true
+		case s390xasm.RegIm32:
+			return uint64(int64(inst.PC) + 2*int64(int32(rel))), true
+		}
+	}
+	return 0, false
+}
+
+func (a *s390x) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return s390xasm.GoSyntax(inst.Raw.(s390xasm.Inst), inst.PC, lookup)
+}
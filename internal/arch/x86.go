@@ -0,0 +1,356 @@
+package arch
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/arch/x86/x86asm"
+)
+
+type x86 struct {
+	name string
+	mode int
+}
+
+func (a *x86) Name() string { return a.name }
+
+func (a *x86) MinInstLen() int { return 1 }
+
+func (a *x86) Decode(mem []byte, pc uint64) (*Inst, error) {
+	inst, err := x86asm.Decode(mem, a.mode)
+	if err != nil {
+		return nil, err
+	}
+	return &Inst{PC: pc, Len: inst.Len, Op: inst.Op.String(), Raw: inst}, nil
+}
+
+func (a *x86) Unknown(pc uint64, size int) *Inst {
+	return &Inst{PC: pc, Len: size, Op: UnknownOp, Raw: x86asm.Inst{}}
+}
+
+func (a *x86) CallTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(x86asm.Inst)
+	switch raw.Op {
+	case x86asm.CALL, x86asm.LCALL:
+		return a.relTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *x86) BranchTarget(inst *Inst) (uint64, bool) {
+	raw := inst.Raw.(x86asm.Inst)
+	switch raw.Op {
+	case x86asm.JMP, x86asm.LJMP,
+		x86asm.JA, x86asm.JAE, x86asm.JB, x86asm.JBE, x86asm.JCXZ, x86asm.JE, x86asm.JECXZ,
+		x86asm.JG, x86asm.JGE, x86asm.JL, x86asm.JLE, x86asm.JNE, x86asm.JNO, x86asm.JNP,
+		x86asm.JNS, x86asm.JO, x86asm.JP, x86asm.JRCXZ, x86asm.JS,
+		x86asm.LOOP, x86asm.LOOPE, x86asm.LOOPNE:
+		return a.relTarget(inst, raw)
+	}
+	return 0, false
+}
+
+func (a *x86) relTarget(inst *Inst, raw x86asm.Inst) (uint64, bool) {
+	rel, ok := raw.Args[0].(x86asm.Rel)
+	if !ok {
+		return 0, false
+	}
+	return uint64(int64(inst.PC) + int64(inst.Len) + int64(rel)), true
+}
+
+func (a *x86) GoSyntax(inst *Inst, lookup SymLookup) string {
+	return x86asm.GoSyntax(inst.Raw.(x86asm.Inst), inst.PC, x86asm.SymLookup(lookup))
+}
+
+// maxIndirectScan is the maximum number of instructions to scan backward for the load of the callee address.
+const maxIndirectScan = 16
+
+func (a *x86) IndirectCallee(insts []*Inst, i int) (int, string, int64, bool) {
+	call := insts[i].Raw.(x86asm.Inst)
+	if call.Op != x86asm.CALL {
+		return 0, "", 0, false
+	}
+	reg, ok := call.Args[0].(x86asm.Reg)
+	if !ok {
+		return 0, "", 0, false
+	}
+	for j := i - 1; j >= 0 && j >= i-maxIndirectScan; j-- {
+		raw := insts[j].Raw.(x86asm.Inst)
+		if _, ok := a.BranchTarget(insts[j]); ok || raw.Op == x86asm.CALL || raw.Op == x86asm.RET {
+			return 0, "", 0, false
+		}
+		switch raw.Op {
+		case x86asm.CMP, x86asm.TEST, x86asm.PUSH:
+			continue
+		}
+		if dst, ok := raw.Args[0].(x86asm.Reg); !ok || dst != reg {
+			continue
+		}
+		if src, ok := raw.Args[1].(x86asm.Reg); ok && raw.Op == x86asm.MOV {
+			// the callee address is loaded into another register first ( e.g. MOVQ 0x18(AX), AX; MOVQ AX, SI; CALL SI ).
+			reg = src
+			continue
+		}
+		mem, ok := raw.Args[1].(x86asm.Mem)
+		if raw.Op != x86asm.MOV || !ok || mem.Base == 0 || mem.Base == x86asm.RIP || mem.Index != 0 {
+			return 0, "", 0, false
+		}
+		return j, x86RegFamily(mem.Base).String(), mem.Disp, true
+	}
+	return 0, "", 0, false
+}
+
+func (a *x86) AddrRef(insts []*Inst, i int) (uint64, bool) {
+	inst := insts[i]
+	raw := inst.Raw.(x86asm.Inst)
+	if raw.Op != x86asm.LEA {
+		return 0, false
+	}
+	mem, ok := raw.Args[1].(x86asm.Mem)
+	if !ok || mem.Index != 0 {
+		return 0, false
+	}
+	switch mem.Base {
+	case x86asm.RIP:
+		return uint64(int64(inst.PC) + int64(inst.Len) + mem.Disp), true
+	case 0:
+		// absolute address in 32-bit mode.
+		return uint64(uint32(mem.Disp)), true
+	}
+	return 0, false
+}
+
+// x86ImplicitWrites is the instructions that write the registers other than the destination operand.
+var x86ImplicitWrites = map[x86asm.Op]struct{}{
+	x86asm.MUL: {}, x86asm.DIV: {}, x86asm.IDIV: {}, x86asm.CQO: {}, x86asm.CDQ: {}, x86asm.CWD: {},
+	x86asm.XCHG: {}, x86asm.XADD: {}, x86asm.CMPXCHG: {}, x86asm.CMPXCHG8B: {}, x86asm.CMPXCHG16B: {},
+	x86asm.CPUID: {}, x86asm.RDTSC: {}, x86asm.RDTSCP: {}, x86asm.SYSCALL: {}, x86asm.SYSENTER: {}, x86asm.INT: {},
+	x86asm.LEAVE: {}, x86asm.ENTER: {},
+	x86asm.MOVSB: {}, x86asm.MOVSW: {}, x86asm.MOVSD: {}, x86asm.MOVSQ: {},
+	x86asm.STOSB: {}, x86asm.STOSW: {}, x86asm.STOSD: {}, x86asm.STOSQ: {},
+	x86asm.LODSB: {}, x86asm.LODSW: {}, x86asm.LODSD: {}, x86asm.LODSQ: {},
+	x86asm.SCASB: {}, x86asm.SCASW: {}, x86asm.SCASD: {}, x86asm.SCASQ: {},
+	x86asm.CMPSB: {}, x86asm.CMPSW: {}, x86asm.CMPSD: {}, x86asm.CMPSQ: {},
+}
+
+func (a *x86) TraceBack(insts []*Inst, i int, loc Loc) (Loc, uint64, bool) {
+	inst := insts[i]
+	if inst.Op == UnknownOp {
+		return Loc{}, 0, false
+	}
+	raw := inst.Raw.(x86asm.Inst)
+	if _, ok := a.BranchTarget(inst); ok {
+		return loc, 0, true
+	}
+	ptrSize := int64(a.mode / 8)
+	switch raw.Op {
+	case x86asm.CALL:
+		// the callee does not preserve the registers, but it does not write the frame of the caller.
+		return loc, 0, loc.Reg == ""
+	case x86asm.CMP, x86asm.TEST, x86asm.NOP:
+		return loc, 0, true
+	case x86asm.PUSH:
+		if loc.Reg != "" {
+			return loc, 0, true
+		}
+		if loc.Off >= ptrSize {
+			return Loc{Off: loc.Off - ptrSize}, 0, true
+		}
+		if src, ok := raw.Args[0].(x86asm.Reg); ok && loc.Off == 0 {
+			return Loc{Reg: x86RegFamily(src).String()}, 0, true
+		}
+		return Loc{}, 0, false
+	case x86asm.POP:
+		if loc.Reg == "" {
+			return Loc{Off: loc.Off + ptrSize}, 0, true
+		}
+		if dst, ok := raw.Args[0].(x86asm.Reg); ok && x86RegFamily(dst).String() == loc.Reg {
+			return Loc{Off: 0}, 0, true
+		}
+		return loc, 0, true
+	}
+	if loc.Reg != "" {
+		if _, exists := x86ImplicitWrites[raw.Op]; exists {
+			return Loc{}, 0, false
+		}
+		if dst, ok := raw.Args[0].(x86asm.Reg); !ok || x86RegFamily(dst).String() != loc.Reg {
+			return loc, 0, true
+		}
+		if addr, ok := a.AddrRef(insts, i); ok {
+			return Loc{}, addr, true
+		}
+		if raw.Op != x86asm.MOV {
+			return Loc{}, 0, false
+		}
+		switch src := raw.Args[1].(type) {
+		case x86asm.Reg:
+			return Loc{Reg: x86RegFamily(src).String()}, 0, true
+		case x86asm.Mem:
+			if x86RegFamily(src.Base) == x86asm.RSP && src.Index == 0 {
+				return Loc{Off: src.Disp}, 0, true
+			}
+		}
+		return Loc{}, 0, false
+	}
+	switch dst := raw.Args[0].(type) {
+	case x86asm.Reg:
+		if x86RegFamily(dst) != x86asm.RSP {
+			return loc, 0, true
+		}
+		imm, ok := raw.Args[1].(x86asm.Imm)
+		switch {
+		case ok && raw.Op == x86asm.SUB:
+			return Loc{Off: loc.Off - int64(imm)}, 0, true
+		case ok && raw.Op == x86asm.ADD:
+			return Loc{Off: loc.Off + int64(imm)}, 0, true
+		}
+		return Loc{}, 0, false
+	case x86asm.Mem:
+		if x86RegFamily(dst.Base) != x86asm.RSP {
+			return loc, 0, true
+		}
+		if dst.Index != 0 {
+			return Loc{}, 0, false
+		}
+		if loc.Off+ptrSize <= dst.Disp || dst.Disp+int64(raw.MemBytes) <= loc.Off {
+			return loc, 0, true
+		}
+		if src, ok := raw.Args[1].(x86asm.Reg); ok && raw.Op == x86asm.MOV && dst.Disp == loc.Off {
+			return Loc{Reg: x86RegFamily(src).String()}, 0, true
+		}
+		return Loc{}, 0, false
+	}
+	return loc, 0, true
+}
+
+// CallerLoc takes the return address that the call pushes on the stack into account.
+func (a *x86) CallerLoc(loc Loc) (Loc, bool) {
+	ptrSize := int64(a.mode / 8)
+	switch {
+	case loc.Reg == x86asm.RSP.String():
+		return Loc{}, false
+	case loc.Reg != "":
+		return loc, true
+	case loc.Off < ptrSize:
+		return Loc{}, false
+	}
+	return Loc{Off: loc.Off - ptrSize}, true
+}
+
+func (a *x86) Flow(inst *Inst) FlowKind {
+	raw := inst.Raw.(x86asm.Inst)
+	switch raw.Op {
+	case x86asm.RET, x86asm.LRET:
+		return FlowReturn
+	case x86asm.UD1, x86asm.UD2, x86asm.HLT:
+		return FlowTrap
+	case x86asm.INT:
+		if imm, ok := raw.Args[0].(x86asm.Imm); ok && imm == 3 {
+			return FlowTrap
+		}
+	case x86asm.JMP, x86asm.LJMP:
+		if _, ok := a.BranchTarget(inst); ok {
+			return FlowJump
+		}
+		return FlowIndirectJump
+	}
+	if _, ok := a.BranchTarget(inst); ok {
+		return FlowCondJump
+	}
+	return FlowNext
+}
+
+// maxJumpTableLen is the maximum number of the entries of a jump table to read.
+const maxJumpTableLen = 1 << 12
+
+// JumpTable resolves the jump table that the compiler emits for amd64 as follows.
+//
+//	CMPQ CX, $0x17
+//	JA default
+//	LEAQ table(SB), DX
+//	JMP 0(DX)(CX*8)
+//
+// The entries of the table are the absolute addresses of the cases, and the comparison ( or the mask by AND ) decides their number.
+func (a *x86) JumpTable(insts []*Inst, i int, read ReadMemory) ([]uint64, bool) {
+	jmp := insts[i].Raw.(x86asm.Inst)
+	if jmp.Op != x86asm.JMP {
+		return nil, false
+	}
+	mem, ok := jmp.Args[0].(x86asm.Mem)
+	entrySize := a.mode / 8
+	if !ok || mem.Base == 0 || mem.Index == 0 || int(mem.Scale) != entrySize || mem.Segment != 0 {
+		return nil, false
+	}
+	var (
+		table, n uint64
+		base     = x86RegFamily(mem.Base)
+		index    = x86RegFamily(mem.Index)
+	)
+	for j := i - 1; j >= 0 && j >= i-maxIndirectScan && (table == 0 || n == 0); j-- {
+		raw := insts[j].Raw.(x86asm.Inst)
+		switch raw.Op {
+		case x86asm.CALL, x86asm.RET, x86asm.JMP:
+			return nil, false
+		}
+		dst, ok := raw.Args[0].(x86asm.Reg)
+		if !ok {
+			continue
+		}
+		imm, isImm := raw.Args[1].(x86asm.Imm)
+		switch {
+		case x86RegFamily(dst) == base && table == 0:
+			addr, ok := a.AddrRef(insts, j)
+			if !ok {
+				return nil, false
+			}
+			table = addr
+		case x86RegFamily(dst) == index && n == 0:
+			switch {
+			case raw.Op == x86asm.CMP && isImm:
+				n = uint64(imm) + 1
+			case raw.Op == x86asm.AND && isImm:
+				n = uint64(imm) + 1
+			case raw.Op == x86asm.CMP || raw.Op == x86asm.TEST:
+			default:
+				// the index is computed without a bound.
+				return nil, false
+			}
+		}
+	}
+	if table == 0 || n == 0 || n > maxJumpTableLen {
+		return nil, false
+	}
+	data, err := read(table+uint64(mem.Disp), int(n)*entrySize)
+	if err != nil {
+		return nil, false
+	}
+	targets := make([]uint64, n)
+	for k := range targets {
+		if entrySize == 4 {
+			targets[k] = uint64(binary.LittleEndian.Uint32(data[k*4:]))
+		} else {
+			targets[k] = binary.LittleEndian.Uint64(data[k*8:])
+		}
+	}
+	return targets, true
+}
+
+func (a *x86) Padding(inst *Inst) bool {
+	raw := inst.Raw.(x86asm.Inst)
+	imm, ok := raw.Args[0].(x86asm.Imm)
+	return raw.Op == x86asm.INT && ok && imm == 3
+}
+
+// x86RegFamily returns the 64-bit register that contains the general purpose register r ( e.g. RCX for ECX ).
+func x86RegFamily(r x86asm.Reg) x86asm.Reg {
+	switch {
+	case x86asm.AL <= r && r <= x86asm.BL:
+		return r - x86asm.AL + x86asm.RAX
+	case x86asm.SPB <= r && r <= x86asm.R15B:
+		return r - x86asm.SPB + x86asm.RSP
+	case x86asm.AX <= r && r <= x86asm.R15W:
+		return r - x86asm.AX + x86asm.RAX
+	case x86asm.EAX <= r && r <= x86asm.R15L:
+		return r - x86asm.EAX + x86asm.RAX
+	}
+	return r
+}
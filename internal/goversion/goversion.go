@@ -0,0 +1,61 @@
+// Package goversion handles the version of the Go toolchain that built a binary.
+package goversion
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a Go release version. Only major and minor versions are kept
+// because the runtime data layout never changes in patch releases.
+type Version struct {
+	Major int
+	Minor int
+}
+
+// Parse parses the version string embedded in Go binaries ( e.g. "go1.21.3", "go1.22rc1", "devel go1.27-abcdef ..." ).
+func Parse(s string) (Version, error) {
+	v := strings.TrimSpace(s)
+	v = strings.TrimPrefix(v, "devel ")
+	if !strings.HasPrefix(v, "go") {
+		return Version{}, fmt.Errorf("invalid Go version %q", s)
+	}
+	v = v[len("go"):]
+	major, rest := leadingInt(v)
+	if major < 0 || !strings.HasPrefix(rest, ".") {
+		return Version{}, fmt.Errorf("invalid Go version %q", s)
+	}
+	minor, _ := leadingInt(rest[1:])
+	if minor < 0 {
+		return Version{}, fmt.Errorf("invalid Go version %q", s)
+	}
+	return Version{Major: major, Minor: minor}, nil
+}
+
+func leadingInt(s string) (int, string) {
+	i := 0
+	for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return -1, s
+	}
+	n, err := strconv.Atoi(s[:i])
+	if err != nil {
+		return -1, s
+	}
+	return n, s[i:]
+}
+
+// AtLeast reports whether v is go1.minor or later.
+func (v Version) AtLeast(minor int) bool {
+	if v.Major != 1 {
+		return v.Major > 1
+	}
+	return v.Minor >= minor
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("go%d.%d", v.Major, v.Minor)
+}
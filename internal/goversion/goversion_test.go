@@ -0,0 +1,33 @@
+package goversion_test
+
+import (
+	"testing"
+
+	"github.com/goccy/binarian/internal/goversion"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want goversion.Version
+	}{
+		{"go1.16", goversion.Version{Major: 1, Minor: 16}},
+		{"go1.21.3", goversion.Version{Major: 1, Minor: 21}},
+		{"go1.22rc1", goversion.Version{Major: 1, Minor: 22}},
+		{"devel go1.27-0123456 Mon Oct 12 00:00:00 2026 +0000", goversion.Version{Major: 1, Minor: 27}},
+	}
+	for _, test := range tests {
+		got, err := goversion.Parse(test.in)
+		if err != nil {
+			t.Fatalf("%s: %+v", test.in, err)
+		}
+		if got != test.want {
+			t.Fatalf("%s: expected %v but got %v", test.in, test.want, got)
+		}
+	}
+	for _, in := range []string{"", "1.21", "go", "gox.1"} {
+		if _, err := goversion.Parse(in); err == nil {
+			t.Fatalf("%q: expected error", in)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package reflect
+
+import (
+	"github.com/goccy/binarian/reflect"
+)
+
+// The functions in this file are ported from the reflect package.
+// The linker deduplicates type descriptors, so types in the same binary are identical if they are at the same offset.
+
+func (t *Type) same(u *Type) bool {
+	if t == nil || u == nil {
+		return t == u
+	}
+	return t.mod == u.mod && t.offset == u.offset
+}
+
+func toType(t reflect.Type) *Type {
+	if t == nil {
+		return nil
+	}
+	return t.(*Type)
+}
+
+// implements reports whether the type V implements the interface type T.
+func implements(T, V *Type) bool {
+	if T.Kind() != reflect.Interface {
+		return false
+	}
+	t, err := T.toInterfaceType()
+	if err != nil {
+		panic(err)
+	}
+	if len(t.methods) == 0 {
+		return true
+	}
+
+	// The same algorithm applies in both cases, but the method tables for an interface type and a concrete type are different,
+	// so the code is duplicated. In both cases the algorithm is a linear scan over the two lists - T's methods and V's methods -
+	// simultaneously. Since method tables are stored in a unique sorted order ( alphabetical, with no duplicate method names ),
+	// the scan through V's methods must hit a match for each of T's methods along the way, or else V does not implement T.
+	if V.Kind() == reflect.Interface {
+		v, err := V.toInterfaceType()
+		if err != nil {
+			panic(err)
+		}
+		i := 0
+		for j := 0; j < len(v.methods); j++ {
+			tm := &t.methods[i]
+			vm := &v.methods[j]
+			if !matchMethod(T, tm.name, tm.typ, t.pkgPath, V, vm.name, vm.typ, v.pkgPath) {
+				continue
+			}
+			if i++; i >= len(t.methods) {
+				return true
+			}
+		}
+		return false
+	}
+
+	ut, _ := V.uncommon()
+	if ut == nil {
+		return false
+	}
+	i := 0
+	vmethods := V.methods()
+	for j := 0; j < len(vmethods); j++ {
+		tm := &t.methods[i]
+		vm := &vmethods[j]
+		if !matchMethod(T, tm.name, tm.typ, t.pkgPath, V, vm.name, vm.mtyp, ut.pkgPath) {
+			continue
+		}
+		if i++; i >= len(t.methods) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchMethod reports whether the method of V has the same name and the same type as the interface method of T.
+// Unexported methods must also be declared in the same package.
+func matchMethod(T *Type, tmName nameOff, tmTyp typeOff, tPkgPath nameOff, V *Type, vmName nameOff, vmTyp typeOff, vPkgPath nameOff) bool {
+	if tmTyp != vmTyp {
+		return false
+	}
+	tname, err := T.mod.readName(tmName)
+	if err != nil {
+		panic(err)
+	}
+	vname, err := V.mod.readName(vmName)
+	if err != nil {
+		panic(err)
+	}
+	if tname.text != vname.text {
+		return false
+	}
+	if tname.isExported() {
+		return true
+	}
+	return methodPkgPath(T.mod, tname, tPkgPath) == methodPkgPath(V.mod, vname, vPkgPath)
+}
+
+// methodPkgPath returns the package path of the unexported method.
+// If the method name has no package path, the package path of the type is used.
+func methodPkgPath(mod *Module, n *name, typePkgPath nameOff) string {
+	pkgPath, err := mod.namePkgPath(n)
+	if err != nil {
+		panic(err)
+	}
+	if pkgPath != "" || typePkgPath == 0 {
+		return pkgPath
+	}
+	pkgPath, err = mod.nameText(typePkgPath)
+	if err != nil {
+		panic(err)
+	}
+	return pkgPath
+}
+
+// specialChannelAssignability reports whether a value x of channel type V can be directly assigned ( using memmove ) to another channel type T.
+// https://golang.org/doc/go_spec.html#Assignability
+// T and V must be both of Chan kind.
+func specialChannelAssignability(T, V *Type) bool {
+	// Special case:
+	// x is a bidirectional channel value, T is a channel type,
+	// x's type V and T have identical element types,
+	// and at least one of V or T is not a defined type.
+	return V.ChanDir() == reflect.BothDir && (T.Name() == "" || V.Name() == "") && haveIdenticalType(toType(T.Elem()), toType(V.Elem()), true)
+}
+
+// directlyAssignable reports whether a value x of type V can be directly assigned ( using memmove ) to a value of type T.
+// https://golang.org/doc/go_spec.html#Assignability
+// Ignoring the interface rules ( implemented elsewhere ) and the ideal constant rules ( no ideal constants at run time ).
+func directlyAssignable(T, V *Type) bool {
+	// x's type V is identical to T?
+	if T.same(V) {
+		return true
+	}
+
+	// Otherwise at least one of T and V must not be defined and they must have the same kind.
+	if T.hasName() && V.hasName() || T.Kind() != V.Kind() {
+		return false
+	}
+
+	if T.Kind() == reflect.Chan && specialChannelAssignability(T, V) {
+		return true
+	}
+
+	// x's type T and V must have identical underlying types.
+	return haveIdenticalUnderlyingType(T, V, true)
+}
+
+func haveIdenticalType(T, V *Type, cmpTags bool) bool {
+	if cmpTags {
+		return T.same(V)
+	}
+
+	if T.Name() != V.Name() || T.Kind() != V.Kind() || T.PkgPath() != V.PkgPath() {
+		return false
+	}
+
+	return haveIdenticalUnderlyingType(T, V, false)
+}
+
+func haveIdenticalUnderlyingType(T, V *Type, cmpTags bool) bool {
+	if T.same(V) {
+		return true
+	}
+
+	kind := T.Kind()
+	if kind != V.Kind() {
+		return false
+	}
+
+	// Non-composite types of equal kind have same underlying type ( the predefined instance of the type ).
+	if reflect.Bool <= kind && kind <= reflect.Complex128 || kind == reflect.String || kind == reflect.UnsafePointer {
+		return true
+	}
+
+	// Composite types.
+	switch kind {
+	case reflect.Array:
+		return T.Len() == V.Len() && haveIdenticalType(toType(T.Elem()), toType(V.Elem()), cmpTags)
+
+	case reflect.Chan:
+		return V.ChanDir() == T.ChanDir() && haveIdenticalType(toType(T.Elem()), toType(V.Elem()), cmpTags)
+
+	case reflect.Func:
+		if T.IsVariadic() != V.IsVariadic() || T.NumIn() != V.NumIn() || T.NumOut() != V.NumOut() {
+			return false
+		}
+		for i := 0; i < T.NumIn(); i++ {
+			if !haveIdenticalType(toType(T.In(i)), toType(V.In(i)), cmpTags) {
+				return false
+			}
+		}
+		for i := 0; i < T.NumOut(); i++ {
+			if !haveIdenticalType(toType(T.Out(i)), toType(V.Out(i)), cmpTags) {
+				return false
+			}
+		}
+		return true
+
+	case reflect.Interface:
+		// Might have the same methods but still need a run time conversion.
+		return T.NumMethod() == 0 && V.NumMethod() == 0
+
+	case reflect.Map:
+		return haveIdenticalType(toType(T.Key()), toType(V.Key()), cmpTags) && haveIdenticalType(toType(T.Elem()), toType(V.Elem()), cmpTags)
+
+	case reflect.Ptr, reflect.Slice:
+		return haveIdenticalType(toType(T.Elem()), toType(V.Elem()), cmpTags)
+
+	case reflect.Struct:
+		t, err := T.toStructType()
+		if err != nil {
+			panic(err)
+		}
+		v, err := V.toStructType()
+		if err != nil {
+			panic(err)
+		}
+		if len(t.fields) != len(v.fields) {
+			return false
+		}
+		if t.pkgPath != 0 && v.pkgPath != 0 {
+			tPkgPath, err := T.mod.nameText(t.pkgPath)
+			if err != nil {
+				panic(err)
+			}
+			vPkgPath, err := V.mod.nameText(v.pkgPath)
+			if err != nil {
+				panic(err)
+			}
+			if tPkgPath != vPkgPath {
+				return false
+			}
+		} else if t.pkgPath != v.pkgPath {
+			return false
+		}
+		for i := range t.fields {
+			tf := &t.fields[i]
+			vf := &v.fields[i]
+			tname, err := T.mod.readName(tf.name)
+			if err != nil {
+				panic(err)
+			}
+			vname, err := V.mod.readName(vf.name)
+			if err != nil {
+				panic(err)
+			}
+			if tname.text != vname.text {
+				return false
+			}
+			if !haveIdenticalType(tf.typ, vf.typ, cmpTags) {
+				return false
+			}
+			if cmpTags && tname.tag != vname.tag {
+				return false
+			}
+			if tf.offset != vf.offset {
+				return false
+			}
+			if tf.embedded != vf.embedded {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// convertible reports whether a value of type src can be converted to type dst.
+// https://golang.org/doc/go_spec.html#Conversions
+func convertible(dst, src *Type) bool {
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64, reflect.String:
+			return true
+		}
+
+	case reflect.Float32, reflect.Float64:
+		switch dst.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+			return true
+		}
+
+	case reflect.Complex64, reflect.Complex128:
+		switch dst.Kind() {
+		case reflect.Complex64, reflect.Complex128:
+			return true
+		}
+
+	case reflect.String:
+		if dst.Kind() == reflect.Slice && dst.Elem().PkgPath() == "" {
+			switch dst.Elem().Kind() {
+			case reflect.Uint8, reflect.Int32:
+				return true
+			}
+		}
+
+	case reflect.Slice:
+		if dst.Kind() == reflect.String && src.Elem().PkgPath() == "" {
+			switch src.Elem().Kind() {
+			case reflect.Uint8, reflect.Int32:
+				return true
+			}
+		}
+		// "x is a slice, T is a pointer-to-array type, and the slice and array types have identical element types."
+		if dst.Kind() == reflect.Ptr && dst.Elem().Kind() == reflect.Array && toType(src.Elem()).same(toType(dst.Elem().Elem())) {
+			return true
+		}
+		// "x is a slice, T is an array type, and the slice and array types have identical element types."
+		if dst.Kind() == reflect.Array && toType(src.Elem()).same(toType(dst.Elem())) {
+			return true
+		}
+
+	case reflect.Chan:
+		if dst.Kind() == reflect.Chan && specialChannelAssignability(dst, src) {
+			return true
+		}
+	}
+
+	// dst and src have same underlying type.
+	if haveIdenticalUnderlyingType(dst, src, false) {
+		return true
+	}
+
+	// dst and src are non-defined pointer types with same underlying base type.
+	if dst.Kind() == reflect.Ptr && dst.Name() == "" &&
+		src.Kind() == reflect.Ptr && src.Name() == "" {
+		if haveIdenticalUnderlyingType(toType(dst.Elem()), toType(src.Elem()), false) {
+			return true
+		}
+	}
+
+	return implements(dst, src)
+}
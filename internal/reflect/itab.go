@@ -0,0 +1,95 @@
+package reflect
+
+import (
+	"fmt"
+
+	"github.com/goccy/binarian/reflect"
+)
+
+// Itab is the decoded runtime.itab ( internal/abi.ITab ).
+// The compiler generates it for each conversion of a concrete type to a non-empty interface type.
+type Itab struct {
+	Addr  uint64
+	Inter *Type
+	Type  *Type
+	// Fun is the code address of each method of Inter in the order of Inter.Method(i).
+	// It is empty if Type does not implement Inter.
+	Fun []uint64
+}
+
+// ItabByAddr decodes the itab at the virtual address addr.
+func (m *Module) ItabByAddr(addr uint64) (*Itab, error) {
+	offset, err := m.addrToOffset(addr)
+	if err != nil {
+		return nil, err
+	}
+	itab, _, err := m.loadItab(offset)
+	return itab, err
+}
+
+// Itabs decodes the itabs laid out from offset to offset+size of the type data.
+// It is used to enumerate itabs of binaries that have no itablinks.
+func (m *Module) Itabs(offset, size uint64) ([]*Itab, error) {
+	if offset+size > uint64(len(m.data)) {
+		return nil, fmt.Errorf("itab range %#x-%#x is out of type data", offset, offset+size)
+	}
+	var itabs []*Itab
+	for p := int64(offset); p < int64(offset+size); {
+		itab, itabSize, err := m.loadItab(p)
+		if err != nil {
+			return nil, err
+		}
+		itabs = append(itabs, itab)
+		p += int64(itabSize)
+	}
+	return itabs, nil
+}
+
+// loadItab decodes the itab at offset and returns it with its size.
+func (m *Module) loadItab(offset int64) (*Itab, int, error) {
+	d := m.decoder(offset)
+	interAddr := d.uintptr()
+	typAddr := d.uintptr()
+	if d.err != nil {
+		return nil, 0, fmt.Errorf("failed to decode itab at %#x: %w", offset, d.err)
+	}
+	inter, err := m.loadTypeByAddr(interAddr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode interface type of itab at %#x: %w", offset, err)
+	}
+	typ, err := m.loadTypeByAddr(typAddr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to decode concrete type of itab at %#x: %w", offset, err)
+	}
+	if inter == nil || inter.Kind() != reflect.Interface || typ == nil {
+		return nil, 0, fmt.Errorf("invalid itab at %#x", offset)
+	}
+	it, err := inter.toInterfaceType()
+	if err != nil {
+		return nil, 0, err
+	}
+	d = m.decoder(offset + int64(m.layout.ItabFunOffset()))
+	fun := make([]uint64, 0, len(it.methods))
+	for i := 0; i < len(it.methods); i++ {
+		addr := d.uintptr()
+		if i == 0 && addr == 0 {
+			// fun[0] == 0 means that the type does not implement the interface, and the rest of the method table is omitted.
+			fun = nil
+			break
+		}
+		fun = append(fun, addr)
+	}
+	if d.err != nil {
+		return nil, 0, fmt.Errorf("failed to decode method table of itab at %#x: %w", offset, d.err)
+	}
+	size := m.layout.ItabFunOffset() + m.layout.ptrSize
+	if len(fun) > 1 {
+		size += (len(fun) - 1) * m.layout.ptrSize
+	}
+	return &Itab{
+		Addr:  m.addr + uint64(offset),
+		Inter: inter,
+		Type:  typ,
+		Fun:   fun,
+	}, size, nil
+}
@@ -0,0 +1,136 @@
+package reflect
+
+import (
+	"fmt"
+
+	"github.com/goccy/binarian/internal/goversion"
+	"github.com/goccy/binarian/reflect"
+)
+
+// Layout is the memory layout of the runtime type descriptors.
+// It depends on the version of the Go toolchain that built the binary.
+//
+//	go1.16: names have a 2-byte big endian length.
+//	go1.17: names have a varint length.
+//	go1.19: the embedded flag of struct fields moved from the field offset to the name.
+//	go1.21: runtime._type moved to internal/abi.Type ( the layout is unchanged ).
+//	go1.23: runtime.itab moved to internal/abi.ITab without the padding after hash.
+//	go1.24: map types are Swiss tables ( abi.SwissMapType ).
+//	go1.26: the kind byte no longer has the KindDirectIface and KindGCProg bits.
+//	go1.27: map types have split key/elem groups and typelinks are removed.
+type Layout struct {
+	version goversion.Version
+	ptrSize int
+}
+
+// NewLayout returns the Layout for binaries built by the Go toolchain of version v.
+// ptrSize is the pointer size of the target architecture of the binary ( not the host ).
+func NewLayout(v goversion.Version, ptrSize int) (*Layout, error) {
+	if !v.AtLeast(16) {
+		return nil, fmt.Errorf("unsupported Go version %s. go1.16 or later is supported", v)
+	}
+	if ptrSize != 4 && ptrSize != 8 {
+		return nil, fmt.Errorf("unsupported pointer size %d", ptrSize)
+	}
+	return &Layout{version: v, ptrSize: ptrSize}, nil
+}
+
+// Version returns the Go version of the layout.
+func (l *Layout) Version() goversion.Version {
+	return l.version
+}
+
+// PtrSize returns the pointer size of the target architecture.
+func (l *Layout) PtrSize() int {
+	return l.ptrSize
+}
+
+// ItabFunOffset returns the offset of the method table in runtime.itab ( internal/abi.ITab ).
+// Until Go 1.22, runtime.itab has 4 bytes of padding after hash even on 32-bit architectures.
+func (l *Layout) ItabFunOffset() int {
+	if l.version.AtLeast(23) {
+		return l.align(2*l.ptrSize + 4) // inter, _type, hash
+	}
+	return 2*l.ptrSize + 8 // inter, _type, hash, _ [4]byte
+}
+
+// HasTypelinks reports whether the binary has a typelinks table.
+// Since Go 1.27, types are enumerated by walking the type descriptors instead.
+func (l *Layout) HasTypelinks() bool {
+	return !l.version.AtLeast(27)
+}
+
+// HasItablinks reports whether the binary has an itablinks table.
+// Since Go 1.27, itabs are laid out in the type data instead.
+func (l *Layout) HasItablinks() bool {
+	return !l.version.AtLeast(27)
+}
+
+func (l *Layout) kindMask() uint8 {
+	if l.version.AtLeast(26) {
+		return 0xff
+	}
+	return (1 << 5) - 1
+}
+
+func (l *Layout) legacyName() bool {
+	return !l.version.AtLeast(17)
+}
+
+func (l *Layout) embeddedInOffset() bool {
+	return !l.version.AtLeast(19)
+}
+
+func (l *Layout) align(v int) int {
+	return (v + l.ptrSize - 1) &^ (l.ptrSize - 1)
+}
+
+// typeSize is the size of runtime._type ( internal/abi.Type ).
+func (l *Layout) typeSize() int {
+	return 4*l.ptrSize + 16
+}
+
+func (l *Layout) uncommonTypeSize() int { return 16 }
+
+func (l *Layout) methodSize() int { return 16 }
+
+func (l *Layout) imethodSize() int { return 8 }
+
+func (l *Layout) structFieldSize() int { return 3 * l.ptrSize }
+
+// kindTypeSize returns the size of the kind specific type ( e.g. structType for reflect.Struct ).
+// uncommonType follows it.
+func (l *Layout) kindTypeSize(kind reflect.Kind) int {
+	p := l.ptrSize
+	t := l.typeSize()
+	switch kind {
+	case reflect.Array:
+		return t + 3*p // elem, slice, len
+	case reflect.Chan:
+		return t + 2*p // elem, dir
+	case reflect.Func:
+		return l.align(t + 4) // inCount, outCount
+	case reflect.Interface, reflect.Struct:
+		return t + 4*p // pkgPath, methods ( fields )
+	case reflect.Map:
+		return l.mapTypeSize()
+	case reflect.Ptr, reflect.Slice:
+		return t + p // elem
+	}
+	return t
+}
+
+func (l *Layout) mapTypeSize() int {
+	p := l.ptrSize
+	t := l.typeSize()
+	switch {
+	case l.version.AtLeast(27):
+		// key, elem, group, hasher, groupSize, keysOff, keyStride, elemsOff, elemStride, elemOff, flags
+		return l.align(t + 10*p + 4)
+	case l.version.AtLeast(24):
+		// key, elem, group, hasher, groupSize, slotSize, elemOff, flags
+		return l.align(t + 7*p + 4)
+	}
+	// key, elem, bucket, hasher, keysize, valuesize, bucketsize, flags
+	return t + 4*p + 8
+}
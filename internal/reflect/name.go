@@ -0,0 +1,88 @@
+package reflect
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	nameFlagExported = 1 << 0
+	nameFlagTag      = 1 << 1
+	nameFlagPkgPath  = 1 << 2
+	nameFlagEmbedded = 1 << 3
+)
+
+// name is the decoded runtime.name.
+//
+//	flags ( 1 byte )
+//	length of text ( varint. 2 bytes big endian until Go 1.16 )
+//	text
+//	length of tag and tag ( if nameFlagTag is set )
+//	nameOff of the package path ( 4 bytes. if nameFlagPkgPath is set )
+type name struct {
+	flags   byte
+	text    string
+	tag     string
+	pkgPath nameOff
+}
+
+func (n *name) isExported() bool {
+	return n.flags&nameFlagExported != 0
+}
+
+func (n *name) hasTag() bool {
+	return n.flags&nameFlagTag != 0
+}
+
+func (n *name) hasPkgPath() bool {
+	return n.flags&nameFlagPkgPath != 0
+}
+
+// isEmbedded reports whether the struct field is embedded. It is available since Go 1.19.
+func (n *name) isEmbedded() bool {
+	return n.flags&nameFlagEmbedded != 0
+}
+
+func (m *Module) readName(off nameOff) (*name, error) {
+	d := m.decoder(int64(off))
+	n := &name{flags: d.uint8()}
+	n.text = string(d.read(m.nameLen(d)))
+	if n.hasTag() {
+		n.tag = string(d.read(m.nameLen(d)))
+	}
+	if n.hasPkgPath() {
+		n.pkgPath = nameOff(d.int32())
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("failed to decode name at %#x: %w", off, d.err)
+	}
+	return n, nil
+}
+
+func (m *Module) nameLen(d *decoder) int {
+	if m.layout.legacyName() {
+		// the length is big endian regardless of the byte order of the binary.
+		b := d.read(2)
+		if b == nil {
+			return 0
+		}
+		return int(binary.BigEndian.Uint16(b))
+	}
+	return int(d.uvarint())
+}
+
+func (m *Module) nameText(off nameOff) (string, error) {
+	n, err := m.readName(off)
+	if err != nil {
+		return "", err
+	}
+	return n.text, nil
+}
+
+// namePkgPath returns the package path embedded in the name. It returns empty string if the name has no package path.
+func (m *Module) namePkgPath(n *name) (string, error) {
+	if !n.hasPkgPath() {
+		return "", nil
+	}
+	return m.nameText(n.pkgPath)
+}
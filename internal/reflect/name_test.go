@@ -0,0 +1,79 @@
+package reflect
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/goccy/binarian/internal/goversion"
+)
+
+func TestReadName(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	tag := `json:"` + long + `"`
+	varint := func(v int) []byte {
+		b := make([]byte, binary.MaxVarintLen64)
+		return b[:binary.PutUvarint(b, uint64(v))]
+	}
+	for _, test := range []struct {
+		version goversion.Version
+		data    []byte
+	}{
+		{
+			// 2-byte big endian length.
+			version: goversion.Version{Major: 1, Minor: 16},
+			data: concat(
+				[]byte{0, 0, 4}, []byte("main"), []byte{0}, // package path at offset 0
+				[]byte{nameFlagTag | nameFlagPkgPath}, // name at offset 8
+				[]byte{byte(len(long) >> 8), byte(len(long))}, []byte(long),
+				[]byte{byte(len(tag) >> 8), byte(len(tag))}, []byte(tag),
+				[]byte{0, 0, 0, 0},
+			),
+		},
+		{
+			// varint length.
+			version: goversion.Version{Major: 1, Minor: 17},
+			data: concat(
+				[]byte{0, 4}, []byte("main"), []byte{0, 0},
+				[]byte{nameFlagTag | nameFlagPkgPath},
+				varint(len(long)), []byte(long),
+				varint(len(tag)), []byte(tag),
+				[]byte{0, 0, 0, 0},
+			),
+		},
+	} {
+		layout, err := NewLayout(test.version, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mod := NewModule(layout, 0, test.data, 0, binary.LittleEndian)
+		n, err := mod.readName(8)
+		if err != nil {
+			t.Fatalf("%s: %+v", test.version, err)
+		}
+		if n.text != long {
+			t.Fatalf("%s: failed to decode long name. got %d bytes", test.version, len(n.text))
+		}
+		if n.tag != tag {
+			t.Fatalf("%s: unexpected tag %q", test.version, n.tag)
+		}
+		if n.isExported() {
+			t.Fatalf("%s: expected unexported name", test.version)
+		}
+		pkgPath, err := mod.namePkgPath(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkgPath != "main" {
+			t.Fatalf("%s: unexpected pkgPath %q", test.version, pkgPath)
+		}
+	}
+}
+
+func concat(bs ...[]byte) []byte {
+	var ret []byte
+	for _, b := range bs {
+		ret = append(ret, b...)
+	}
+	return ret
+}
@@ -1,57 +1,87 @@
 package reflect
 
 import (
-	"bytes"
 	"encoding/binary"
 	"fmt"
-	"unsafe"
 
 	"github.com/goccy/binarian/reflect"
 )
 
+// Module is the type data of a binary ( from runtime.moduledata.types to etypes ).
+// Types decoded from the same binary share the Module.
+type Module struct {
+	layout *Layout
+	addr   uint64
+	data   []byte
+	text   uint64
+	bo     binary.ByteOrder
+}
+
+// NewModule creates Module from the type data. addr is the virtual address of data[0].
+// text is the virtual address that the code offsets of methods ( textOff ) are relative to ( runtime.moduledata.text ).
+func NewModule(layout *Layout, addr uint64, data []byte, text uint64, bo binary.ByteOrder) *Module {
+	return &Module{
+		layout: layout,
+		addr:   addr,
+		data:   data,
+		text:   text,
+		bo:     bo,
+	}
+}
+
+// TypeByOffset returns the type at offset from the head of the type data ( typeOff ).
+func (m *Module) TypeByOffset(offset int32) (*Type, error) {
+	return m.loadType(int64(offset))
+}
+
+// TypeOffsets returns the offsets of all type descriptors laid out in the first typedesclen bytes of the type data.
+// It is used to enumerate types of binaries that have no typelinks.
+func (m *Module) TypeOffsets(typedesclen uint64) ([]int32, error) {
+	if typedesclen > uint64(len(m.data)) {
+		return nil, fmt.Errorf("type descriptor length %d is larger than type data", typedesclen)
+	}
+	var offsets []int32
+	// the first word is reserved so that typeOff 0 is not a valid type.
+	for offset := m.layout.ptrSize; offset < int(typedesclen); {
+		typ, err := m.loadType(int64(offset))
+		if err != nil {
+			return nil, err
+		}
+		size, err := typ.descriptorSize()
+		if err != nil {
+			return nil, err
+		}
+		offsets = append(offsets, int32(offset))
+		offset = m.layout.align(offset + size)
+	}
+	return offsets, nil
+}
+
 type Type struct {
-	*rtype
-	offset     int32
-	rodataAddr uint64
-	rodata     []byte
-	bo         binary.ByteOrder
+	rtype
+	mod    *Module
+	offset int32
 }
 
 type rtype struct {
-	size       uintptr
-	ptrdata    uintptr
+	size       uint64
+	ptrdata    uint64
 	hash       uint32
 	tflag      tflag
 	align      uint8
 	fieldAlign uint8
 	kind       uint8
-	equal      unsafe.Pointer
-	gcdata     *byte
+	equal      uint64
+	gcdata     uint64
 	str        nameOff
 	ptrToThis  typeOff
 }
 
-type sliceHeader struct {
-	data unsafe.Pointer
-	len  int
-	cap  int
-}
-
-const uintptrSize = 4 << (^uintptr(0) >> 63)
-
 type tflag uint8
 type nameOff int32
 type typeOff int32
 type textOff int32
 
-type name struct {
-	bytes *byte
-}
-
-const (
-	kindMask = (1 << 5) - 1
-)
-
 const (
 	tflagUncommon      tflag = 1 << 0
 	tflagExtraStar     tflag = 1 << 1
@@ -59,31 +89,26 @@ const (
 	tflagRegularMemory tflag = 1 << 3
 )
 
-// size 16 ( 4 + 2 + 2 + 4 + 4 )
 type uncommonType struct {
 	pkgPath nameOff
 	mcount  uint16
 	xcount  uint16
 	moff    uint32
-	_       uint32
 }
 
-// size 70 ( 48 + 8 + 8 + 8 )
 type arrayType struct {
 	rtype
 	elem  *Type
 	slice *Type
-	len   uintptr
+	len   uint64
 }
 
-// size 62 ( 48 + 8 + 8 )
 type chanType struct {
 	rtype
 	elem *Type
-	dir  uintptr
+	dir  uint64
 }
 
-// size 52 ( 48 + 2 + 2 )
 type funcType struct {
 	rtype
 	inCount  uint16
@@ -95,264 +120,299 @@ type imethod struct {
 	typ  typeOff
 }
 
-// size 80 ( 48 + 8 + 24 )
 type interfaceType struct {
 	rtype
-	pkgPath name
+	pkgPath nameOff
 	methods []imethod
 }
 
-// size 88 ( 48 + 8 + 8 + 8 + 8 + 1 + 1 + 2 + 4)
 type mapType struct {
 	rtype
-	key        *Type
-	elem       *Type
-	bucket     *Type
-	hasher     func(unsafe.Pointer, uintptr) uintptr
-	keysize    uint8
-	valuesize  uint8
-	bucketsize uint16
-	flags      uint32
+	key  *Type
+	elem *Type
 }
 
-// size 56 ( 48 + 8 )
 type ptrType struct {
 	rtype
 	elem *Type
 }
 
-// size 54 ( 48 + 8 )
 type sliceType struct {
 	rtype
 	elem *Type
 }
 
-// size 24 ( 8 + 8 + 8 )
 type structField struct {
-	name        name
-	typ         *Type
-	offsetEmbed uintptr
-}
-
-func (f *structField) offset() uintptr {
-	return f.offsetEmbed >> 1
+	name     nameOff
+	typ      *Type
+	offset   uint64
+	embedded bool
 }
 
-func (f *structField) embedded() bool {
-	return f.offsetEmbed&1 != 0
-}
-
-// size 80 ( 48 + 8 + 24 )
 type structType struct {
 	rtype
-	pkgPath name
+	pkgPath nameOff
 	fields  []structField
 }
 
-// size 96 ( 80 + 16 )
-type structTypeUncommon struct {
-	structType
-	u uncommonType
-}
-
-// size 72 ( 56 + 16 )
-type ptrTypeUncommon struct {
-	ptrType
-	u uncommonType
+type method struct {
+	name nameOff
+	mtyp typeOff
+	ifn  textOff
+	tfn  textOff
 }
 
-// size 68 ( 52 + 16 )
-type funcTypeUncommon struct {
-	funcType
-	u uncommonType
+// decoder reads runtime structures field by field in the byte order and the pointer size of the binary.
+// The first error is kept in err and the following reads return zero values.
+type decoder struct {
+	mod    *Module
+	offset int64
+	err    error
 }
 
-// size 70 ( 54 + 16 )
-type sliceTypeUncommon struct {
-	sliceType
-	u uncommonType
+func (m *Module) decoder(offset int64) *decoder {
+	return &decoder{mod: m, offset: offset}
 }
 
-// size 86 ( 70 + 16 )
-type arrayTypeUncommon struct {
-	arrayType
-	u uncommonType
+func (d *decoder) read(size int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if d.offset < 0 || d.offset+int64(size) > int64(len(d.mod.data)) {
+		d.err = fmt.Errorf("failed to read %d bytes at %#x. it is out of type data", size, d.offset)
+		return nil
+	}
+	b := d.mod.data[d.offset : d.offset+int64(size)]
+	d.offset += int64(size)
+	return b
 }
 
-// size 78 ( 62 + 16 )
-type chanTypeUncommon struct {
-	chanType
-	u uncommonType
+func (d *decoder) uint8() uint8 {
+	b := d.read(1)
+	if b == nil {
+		return 0
+	}
+	return b[0]
 }
 
-// size 104 ( 88 + 16 )
-type mapTypeUncommon struct {
-	mapType
-	u uncommonType
+func (d *decoder) uint16() uint16 {
+	b := d.read(2)
+	if b == nil {
+		return 0
+	}
+	return d.mod.bo.Uint16(b)
 }
 
-// size 96 ( 80 + 16 )
-type interfaceTypeUncommon struct {
-	interfaceType
-	u uncommonType
+func (d *decoder) uint32() uint32 {
+	b := d.read(4)
+	if b == nil {
+		return 0
+	}
+	return d.mod.bo.Uint32(b)
 }
 
-// size 64 ( 48 + 16 )
-type defaultTypeUncommon struct {
-	rtype
-	uncommonType
+func (d *decoder) int32() int32 {
+	return int32(d.uint32())
 }
 
-// size 16 ( 4 + 4 + 4 + 4 )
-type method struct {
-	name nameOff
-	mtyp typeOff
-	ifn  textOff
-	tfn  textOff
+func (d *decoder) uintptr() uint64 {
+	b := d.read(d.mod.layout.ptrSize)
+	if b == nil {
+		return 0
+	}
+	if len(b) == 4 {
+		return uint64(d.mod.bo.Uint32(b))
+	}
+	return d.mod.bo.Uint64(b)
 }
 
-var (
-	typeSize          = uint64(unsafe.Sizeof(rtype{}))
-	methodSize        = uint64(unsafe.Sizeof(method{}))
-	imethodSize       = uint64(unsafe.Sizeof(imethod{}))
-	structFieldSize   = uint64(unsafe.Sizeof(structField{}))
-	structTypeSize    = uint64(unsafe.Sizeof(structType{}))
-	ptrTypeSize       = uint64(unsafe.Sizeof(ptrType{}))
-	arrayTypeSize     = uint64(unsafe.Sizeof(arrayType{}))
-	sliceTypeSize     = uint64(unsafe.Sizeof(sliceType{}))
-	mapTypeSize       = uint64(unsafe.Sizeof(mapType{}))
-	chanTypeSize      = uint64(unsafe.Sizeof(chanType{}))
-	funcTypeSize      = uint64(unsafe.Sizeof(funcType{}))
-	interfaceTypeSize = uint64(unsafe.Sizeof(interfaceType{}))
-)
-
-func NewType(rodataAddr uint64, rodata []byte, bo binary.ByteOrder, offset int32) (*Type, error) {
-	var v [48]byte
-	if err := binary.Read(bytes.NewReader(rodata[offset:offset+int32(typeSize)]), bo, &v); err != nil {
-		return nil, err
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
 	}
-	typ := (*rtype)(unsafe.Pointer(&v))
-	return &Type{
-		rtype:      typ,
-		offset:     offset,
-		rodataAddr: rodataAddr,
-		rodata:     rodata,
-		bo:         bo,
-	}, nil
+	if d.offset < 0 || d.offset >= int64(len(d.mod.data)) {
+		d.err = fmt.Errorf("failed to read varint at %#x. it is out of type data", d.offset)
+		return 0
+	}
+	v, n := binary.Uvarint(d.mod.data[d.offset:])
+	if n <= 0 {
+		d.err = fmt.Errorf("failed to read varint at %#x", d.offset)
+		return 0
+	}
+	d.offset += int64(n)
+	return v
 }
 
-func (t *Type) Addr() uintptr {
-	return uintptr(unsafe.Pointer(t.rtype))
+// slice reads a slice header and returns its data address and length.
+func (d *decoder) slice() (uint64, int) {
+	data := d.uintptr()
+	l := d.uintptr()
+	_ = d.uintptr() // cap
+	return data, int(l)
 }
 
-func (t *Type) pointers() bool { return t.ptrdata != 0 }
-
-func (t *Type) common() *Type { return t }
-
-func (t *Type) toStructTypeUncommon() *structTypeUncommon {
-	var v [96]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+96]), t.bo, &v)
-	return (*structTypeUncommon)(unsafe.Pointer(&v))
+// addrToOffset converts the virtual address in the type data to the offset from the head of it.
+func (m *Module) addrToOffset(addr uint64) (int64, error) {
+	if addr < m.addr || addr >= m.addr+uint64(len(m.data)) {
+		return 0, fmt.Errorf("address %#x is out of type data", addr)
+	}
+	return int64(addr - m.addr), nil
 }
 
-func (t *Type) toPtrTypeUncommon() *ptrTypeUncommon {
-	var v [72]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+72]), t.bo, &v)
-	return (*ptrTypeUncommon)(unsafe.Pointer(&v))
+func (m *Module) loadType(offset int64) (*Type, error) {
+	d := m.decoder(offset)
+	var typ rtype
+	typ.size = d.uintptr()
+	typ.ptrdata = d.uintptr()
+	typ.hash = d.uint32()
+	typ.tflag = tflag(d.uint8())
+	typ.align = d.uint8()
+	typ.fieldAlign = d.uint8()
+	typ.kind = d.uint8()
+	typ.equal = d.uintptr()
+	typ.gcdata = d.uintptr()
+	typ.str = nameOff(d.int32())
+	typ.ptrToThis = typeOff(d.int32())
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &Type{
+		rtype:  typ,
+		mod:    m,
+		offset: int32(offset),
+	}, nil
 }
 
-func (t *Type) toFuncTypeUncommon() *funcTypeUncommon {
-	var v [68]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+68]), t.bo, &v)
-	return (*funcTypeUncommon)(unsafe.Pointer(&v))
+// loadTypeByAddr loads the type that a *Type field points to. It returns nil for a nil pointer.
+func (m *Module) loadTypeByAddr(addr uint64) (*Type, error) {
+	if addr == 0 {
+		return nil, nil
+	}
+	offset, err := m.addrToOffset(addr)
+	if err != nil {
+		return nil, err
+	}
+	return m.loadType(offset)
 }
 
-func (t *Type) toSliceTypeUncommon() *sliceTypeUncommon {
-	var v [70]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+70]), t.bo, &v)
-	return (*sliceTypeUncommon)(unsafe.Pointer(&v))
+// nameOffByAddr converts the pointer to runtime.name to nameOff.
+func (m *Module) nameOffByAddr(addr uint64) (nameOff, error) {
+	offset, err := m.addrToOffset(addr)
+	if err != nil {
+		return 0, err
+	}
+	return nameOff(offset), nil
 }
 
-func (t *Type) toArrayTypeUncommon() *arrayTypeUncommon {
-	var v [86]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+86]), t.bo, &v)
-	return (*arrayTypeUncommon)(unsafe.Pointer(&v))
+// textAddr converts textOff to the virtual address of the code.
+// It returns 0 if the linker removed the code because the method is unreachable ( textOff is -1 ).
+// Binaries that split the code into multiple text sections ( very large binaries on ppc64 and arm ) are not supported.
+func (m *Module) textAddr(off textOff) uintptr {
+	if off == -1 {
+		return 0
+	}
+	return uintptr(m.text + uint64(uint32(off)))
 }
 
-func (t *Type) toChanTypeUncommon() *chanTypeUncommon {
-	var v [78]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+78]), t.bo, &v)
-	return (*chanTypeUncommon)(unsafe.Pointer(&v))
+func (t *Type) Addr() uintptr {
+	return uintptr(t.mod.addr + uint64(t.offset))
 }
 
-func (t *Type) toMapTypeUncommon() *mapTypeUncommon {
-	var v [104]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+104]), t.bo, &v)
-	return (*mapTypeUncommon)(unsafe.Pointer(&v))
-}
+func (t *Type) pointers() bool { return t.ptrdata != 0 }
 
-func (t *Type) toInterfaceTypeUncommon() *interfaceTypeUncommon {
-	var v [96]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+96]), t.bo, &v)
-	return (*interfaceTypeUncommon)(unsafe.Pointer(&v))
-}
+func (t *Type) common() *Type { return t }
 
-func (t *Type) toDefaultTypeUncommon() *defaultTypeUncommon {
-	var v [64]byte
-	_ = binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+64]), t.bo, &v)
-	return (*defaultTypeUncommon)(unsafe.Pointer(&v))
+// kindOffset returns the offset of the kind specific fields that follow rtype.
+func (t *Type) kindOffset() int64 {
+	return int64(t.offset) + int64(t.mod.layout.typeSize())
 }
 
-func (t *Type) uncommon() (*uncommonType, uint64) {
+func (t *Type) uncommon() (*uncommonType, int64) {
 	if t.tflag&tflagUncommon == 0 {
 		return nil, 0
 	}
+	offset := int64(t.offset) + int64(t.mod.layout.kindTypeSize(t.Kind()))
+	d := t.mod.decoder(offset)
+	ut := &uncommonType{
+		pkgPath: nameOff(d.int32()),
+		mcount:  d.uint16(),
+		xcount:  d.uint16(),
+		moff:    d.uint32(),
+	}
+	if d.err != nil {
+		return nil, 0
+	}
+	return ut, offset
+}
+
+// descriptorSize returns the size of the type descriptor including the trailing data ( uncommonType, parameters, methods ... ).
+func (t *Type) descriptorSize() (int, error) {
+	layout := t.mod.layout
+	if ut, offset := t.uncommon(); ut != nil {
+		end := offset + int64(ut.moff) + int64(ut.mcount)*int64(layout.methodSize())
+		return int(end - int64(t.offset)), nil
+	}
+	size := layout.kindTypeSize(t.Kind())
 	switch t.Kind() {
-	case reflect.Struct:
-		return &t.toStructTypeUncommon().u, structTypeSize
-	case reflect.Ptr:
-		return &t.toPtrTypeUncommon().u, ptrTypeSize
 	case reflect.Func:
-		return &t.toFuncTypeUncommon().u, funcTypeSize
-	case reflect.Slice:
-		return &t.toSliceTypeUncommon().u, sliceTypeSize
-	case reflect.Array:
-		return &t.toArrayTypeUncommon().u, arrayTypeSize
-	case reflect.Chan:
-		return &t.toChanTypeUncommon().u, chanTypeSize
-	case reflect.Map:
-		return &t.toMapTypeUncommon().u, mapTypeSize
+		tt, err := t.toFuncType()
+		if err != nil {
+			return 0, err
+		}
+		size += (int(tt.inCount) + int(tt.outCount&(1<<15-1))) * layout.ptrSize
 	case reflect.Interface:
-		return &t.toInterfaceTypeUncommon().u, interfaceTypeSize
-	default:
-		return &t.toDefaultTypeUncommon().uncommonType, typeSize
+		d := t.mod.decoder(t.kindOffset() + int64(layout.ptrSize))
+		_, n := d.slice()
+		if d.err != nil {
+			return 0, d.err
+		}
+		size += n * layout.imethodSize()
+	case reflect.Struct:
+		d := t.mod.decoder(t.kindOffset() + int64(layout.ptrSize))
+		_, n := d.slice()
+		if d.err != nil {
+			return 0, d.err
+		}
+		size += n * layout.structFieldSize()
 	}
+	return size, nil
 }
 
-func (t *Type) exportedMethods() []method {
+// methods returns all methods of the type including unexported ones.
+func (t *Type) methods() []method {
 	ut, uncommonOffset := t.uncommon()
 	if ut == nil {
 		return nil
 	}
-	if ut.xcount == 0 {
+	if ut.mcount == 0 {
 		return nil
 	}
-	methods := make([]method, ut.xcount)
-	start := t.offset + int32(uncommonOffset) + int32(ut.moff)
-	end := start + 16
-	for i := 0; i < int(ut.xcount); i++ {
-		var v [16]byte
-		if err := binary.Read(bytes.NewReader(t.rodata[start:end]), t.bo, &v); err != nil {
-			panic(err)
+	methods := make([]method, ut.mcount)
+	d := t.mod.decoder(uncommonOffset + int64(ut.moff))
+	for i := 0; i < int(ut.mcount); i++ {
+		methods[i] = method{
+			name: nameOff(d.int32()),
+			mtyp: typeOff(d.int32()),
+			ifn:  textOff(d.int32()),
+			tfn:  textOff(d.int32()),
 		}
-		methods[i] = *(*method)(unsafe.Pointer(&v))
-		start += 16
-		end += 16
+	}
+	if d.err != nil {
+		panic(d.err)
 	}
 	return methods
 }
 
-func (t *Type) Size() uintptr { return t.size }
+// exportedMethods returns the exported methods. They are sorted in front of the unexported ones.
+func (t *Type) exportedMethods() []method {
+	ut, _ := t.uncommon()
+	if ut == nil || ut.xcount == 0 {
+		return nil
+	}
+	return t.methods()[:ut.xcount]
+}
+
+func (t *Type) Size() uintptr { return uintptr(t.size) }
 
 func (t *Type) Bits() int {
 	if t == nil {
@@ -369,7 +429,7 @@ func (t *Type) Align() int { return int(t.align) }
 
 func (t *Type) FieldAlign() int { return int(t.fieldAlign) }
 
-func (t *Type) Kind() reflect.Kind { return reflect.Kind(t.kind & kindMask) }
+func (t *Type) Kind() reflect.Kind { return reflect.Kind(t.kind & t.mod.layout.kindMask()) }
 
 func (t *Type) Method(i int) (m reflect.Method) {
 	if t.Kind() == reflect.Interface {
@@ -383,21 +443,52 @@ func (t *Type) Method(i int) (m reflect.Method) {
 	if i < 0 || i >= len(methods) {
 		panic("reflect: Method index out of range")
 	}
-	p := methods[i]
+	return t.method(methods[i], i)
+}
+
+// AllMethods returns all methods of the type including unexported ones.
+// Unexported methods are sorted after the exported ones and have PkgPath.
+func (t *Type) AllMethods() []reflect.Method {
+	if t.Kind() == reflect.Interface {
+		tt, err := t.toInterfaceType()
+		if err != nil {
+			panic(err)
+		}
+		methods := make([]reflect.Method, 0, tt.NumMethod())
+		for i := 0; i < tt.NumMethod(); i++ {
+			methods = append(methods, tt.Method(i, t))
+		}
+		return methods
+	}
+	ps := t.methods()
+	methods := make([]reflect.Method, 0, len(ps))
+	for i, p := range ps {
+		methods = append(methods, t.method(p, i))
+	}
+	return methods
+}
+
+func (t *Type) method(p method, i int) (m reflect.Method) {
 	m.Index = i
-	name, err := nameOffToText(p.name, t.rodata, t.bo)
+	pname, err := t.mod.readName(p.name)
 	if err != nil {
 		panic(err)
 	}
-	m.Name = name
-	nameHeader, err := nameOffToHeader(p.name, t.rodata, t.bo)
-	if err != nil {
-		panic(err)
+	m.Name = pname.text
+	if !pname.isExported() {
+		var pkgPath nameOff
+		if ut, _ := t.uncommon(); ut != nil {
+			pkgPath = ut.pkgPath
+		}
+		m.PkgPath = methodPkgPath(t.mod, pname, pkgPath)
 	}
-	if !isExported(nameHeader) || p.mtyp < 0 {
+	m.Ifn = t.mod.textAddr(p.ifn)
+	m.Tfn = t.mod.textAddr(p.tfn)
+	if p.mtyp < 0 {
+		// the linker removed the method type because the method is unreachable.
 		return m
 	}
-	mtyp, err := t.loadType(int32(p.mtyp))
+	mtyp, err := t.mod.loadType(int64(p.mtyp))
 	if err != nil {
 		panic(err)
 	}
@@ -414,7 +505,7 @@ func (t *Type) MethodByName(name string) (reflect.Method, bool) {
 		return tt.MethodByName(name, t)
 	}
 	for i, p := range t.exportedMethods() {
-		text, err := nameOffToText(p.name, t.rodata, t.bo)
+		text, err := t.mod.nameText(p.name)
 		if err != nil {
 			panic(err)
 		}
@@ -443,11 +534,7 @@ func (t *Type) Implements(u reflect.Type) bool {
 	if u.Kind() != reflect.Interface {
 		panic("reflect: non-interface type passed to Type.Implements")
 	}
-	return implements(u.(*Type))
-}
-
-func implements(typ *Type) bool {
-	return false
+	return implements(u.(*Type), t)
 }
 
 func (t *Type) hasName() bool {
@@ -474,7 +561,7 @@ func (t *Type) PkgPath() string {
 	if ut == nil {
 		return ""
 	}
-	text, err := nameOffToText(ut.pkgPath, t.rodata, t.bo)
+	text, err := t.mod.nameText(ut.pkgPath)
 	if err != nil {
 		return ""
 	}
@@ -482,11 +569,11 @@ func (t *Type) PkgPath() string {
 }
 
 func (t *Type) String() string {
-	text, err := nameOffToText(t.str, t.rodata, t.bo)
+	text, err := t.mod.nameText(t.str)
 	if err != nil {
 		return ""
 	}
-	if text[0] == '*' {
+	if t.tflag&tflagExtraStar != 0 && len(text) > 0 {
 		return text[1:]
 	}
 	return text
@@ -496,22 +583,20 @@ func (t *Type) AssignableTo(u reflect.Type) bool {
 	if u == nil {
 		panic("reflect: nil type passed to Type.AssignableTo")
 	}
-	//uu := u.(*Type)
-	return false
-	//return directlyAssignable(uu, t) || implements(uu, t)
+	uu := u.(*Type)
+	return directlyAssignable(uu, t) || implements(uu, t)
 }
 
 func (t *Type) ConvertibleTo(u reflect.Type) bool {
 	if u == nil {
 		panic("reflect: nil type passed to Type.ConvertibleTo")
 	}
-	//uu := u.(*Type)
-	return false
-	//return convertOp(uu, t) != nil
+	uu := u.(*Type)
+	return convertible(uu, t)
 }
 
 func (t *Type) Comparable() bool {
-	return t.equal != nil
+	return t.equal != 0
 }
 
 func (t *Type) ChanDir() reflect.ChanDir {
@@ -537,133 +622,153 @@ func (t *Type) IsVariadic() bool {
 }
 
 func (t *Type) toChanType() (*chanType, error) {
-	var v [64]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+64]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	type chanAddrType struct {
-		rtype
-		elemAddr uint64
-		dir      uintptr
+	d := t.mod.decoder(t.kindOffset())
+	elemAddr := d.uintptr()
+	dir := d.uintptr()
+	if d.err != nil {
+		return nil, d.err
 	}
-	typ := (*chanAddrType)(unsafe.Pointer(&v))
-	elem, err := t.loadType(int32(typ.elemAddr - t.rodataAddr))
+	elem, err := t.mod.loadTypeByAddr(elemAddr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode chan elem type")
+		return nil, fmt.Errorf("failed to decode chan elem type: %w", err)
 	}
 	return &chanType{
-		rtype: typ.rtype,
+		rtype: t.rtype,
 		elem:  elem,
-		dir:   typ.dir,
+		dir:   dir,
 	}, nil
 }
 
 func (t *Type) toInterfaceType() (*interfaceType, error) {
-	var v [80]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+80]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	type interfaceTypeAddr struct {
-		rtype
-		pkgpath name
-		mhdr    sliceHeader
+	d := t.mod.decoder(t.kindOffset())
+	pkgPathAddr := d.uintptr()
+	data, n := d.slice()
+	if d.err != nil {
+		return nil, d.err
+	}
+	var pkgPath nameOff
+	if pkgPathAddr != 0 {
+		off, err := t.mod.nameOffByAddr(pkgPathAddr)
+		if err != nil {
+			return nil, err
+		}
+		pkgPath = off
 	}
-	typ := (*interfaceTypeAddr)(unsafe.Pointer(&v))
-	methods, err := t.toIMethods(typ.mhdr)
+	methods, err := t.toIMethods(data, n)
 	if err != nil {
 		return nil, err
 	}
 	return &interfaceType{
-		rtype:   typ.rtype,
-		pkgPath: typ.pkgpath,
+		rtype:   t.rtype,
+		pkgPath: pkgPath,
 		methods: methods,
 	}, nil
 }
 
-func (t *Type) toIMethods(mhdr sliceHeader) ([]imethod, error) {
-	start := uint64(uintptr(mhdr.data) - uintptr(t.rodataAddr))
-	end := start + imethodSize
-	var methods []imethod
-	for i := uint64(0); i < uint64(mhdr.len); i++ {
-		var hdr uint64
-		if err := binary.Read(bytes.NewReader(t.rodata[start:end]), t.bo, &hdr); err != nil {
-			return nil, err
-		}
-		methods = append(methods, *(*imethod)(unsafe.Pointer(&hdr)))
-		start += imethodSize
-		end += imethodSize
+func (t *Type) toIMethods(data uint64, n int) ([]imethod, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	offset, err := t.mod.addrToOffset(data)
+	if err != nil {
+		return nil, err
+	}
+	d := t.mod.decoder(offset)
+	methods := make([]imethod, 0, n)
+	for i := 0; i < n; i++ {
+		methods = append(methods, imethod{
+			name: nameOff(d.int32()),
+			typ:  typeOff(d.int32()),
+		})
+	}
+	if d.err != nil {
+		return nil, d.err
 	}
 	return methods, nil
 }
 
 func (t *Type) toFuncType() (*funcType, error) {
-	var v [52]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+52]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	return (*funcType)(unsafe.Pointer(&v)), nil
+	d := t.mod.decoder(t.kindOffset())
+	inCount := d.uint16()
+	outCount := d.uint16()
+	if d.err != nil {
+		return nil, d.err
+	}
+	return &funcType{
+		rtype:    t.rtype,
+		inCount:  inCount,
+		outCount: outCount,
+	}, nil
 }
 
 func (t *Type) toStructType() (*structType, error) {
-	var v [80]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+80]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	type structTypeAddr struct {
-		rtype
-		pkgpath   name
-		fieldsHdr sliceHeader
+	d := t.mod.decoder(t.kindOffset())
+	pkgPathAddr := d.uintptr()
+	data, n := d.slice()
+	if d.err != nil {
+		return nil, d.err
+	}
+	var pkgPath nameOff
+	if pkgPathAddr != 0 {
+		off, err := t.mod.nameOffByAddr(pkgPathAddr)
+		if err != nil {
+			return nil, err
+		}
+		pkgPath = off
 	}
-	typ := (*structTypeAddr)(unsafe.Pointer(&v))
-	fields, err := t.toStructFields(typ.fieldsHdr)
+	fields, err := t.toStructFields(data, n)
 	if err != nil {
 		return nil, err
 	}
 	return &structType{
-		rtype:   typ.rtype,
-		pkgPath: typ.pkgpath,
+		rtype:   t.rtype,
+		pkgPath: pkgPath,
 		fields:  fields,
 	}, nil
 }
 
-func (t *Type) toStructFields(hdr sliceHeader) ([]structField, error) {
-	start := uint64(uintptr(hdr.data) - uintptr(t.rodataAddr))
-	end := start + structFieldSize
-	var fields []structField
-	for i := 0; i < hdr.len; i++ {
-		var v [24]byte
-		if err := binary.Read(bytes.NewReader(t.rodata[start:end]), t.bo, &v); err != nil {
-			return nil, err
+func (t *Type) toStructFields(data uint64, n int) ([]structField, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	offset, err := t.mod.addrToOffset(data)
+	if err != nil {
+		return nil, err
+	}
+	d := t.mod.decoder(offset)
+	fields := make([]structField, 0, n)
+	for i := 0; i < n; i++ {
+		nameAddr := d.uintptr()
+		typAddr := d.uintptr()
+		fieldOffset := d.uintptr()
+		if d.err != nil {
+			return nil, d.err
 		}
-		type structFieldAddr struct {
-			name        name
-			typ         uint64
-			offsetEmbed uintptr
+		name, err := t.mod.nameOffByAddr(nameAddr)
+		if err != nil {
+			return nil, err
 		}
-		addr := *(*structFieldAddr)(unsafe.Pointer(&v))
-		typ, err := t.loadType(int32(addr.typ - t.rodataAddr))
+		typ, err := t.mod.loadTypeByAddr(typAddr)
 		if err != nil {
 			return nil, err
 		}
-		fields = append(fields, structField{
-			name:        addr.name,
-			typ:         typ,
-			offsetEmbed: addr.offsetEmbed,
-		})
-		start += structFieldSize
-		end += structFieldSize
+		field := structField{name: name, typ: typ}
+		if t.mod.layout.embeddedInOffset() {
+			field.offset = fieldOffset >> 1
+			field.embedded = fieldOffset&1 != 0
+		} else {
+			n, err := t.mod.readName(name)
+			if err != nil {
+				return nil, err
+			}
+			field.offset = fieldOffset
+			field.embedded = n.isEmbedded()
+		}
+		fields = append(fields, field)
 	}
 	return fields, nil
 }
 
-func (t *Type) loadType(offset int32) (*Type, error) {
-	var v [48]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[offset:offset+48]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	return t.toType((*rtype)(unsafe.Pointer(&v)), offset), nil
-}
-
 func (t *Type) Elem() reflect.Type {
 	typ, err := t.elem()
 	if err != nil {
@@ -699,7 +804,7 @@ func (t *Type) elem() (reflect.Type, error) {
 		}
 		return ptrType.elem, nil
 	case reflect.Slice:
-		sliceType, err := t.toPtrType()
+		sliceType, err := t.toSliceType()
 		if err != nil {
 			return nil, err
 		}
@@ -709,111 +814,80 @@ func (t *Type) elem() (reflect.Type, error) {
 }
 
 func (t *Type) toArrayType() (*arrayType, error) {
-	var v [70]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+70]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	type arrayAddrType struct {
-		rtype
-		elemAddr  uint64
-		sliceAddr uint64
-		len       uintptr
-	}
-	addr := (*arrayAddrType)(unsafe.Pointer(&v))
-	elem, err := t.loadType(int32(addr.elemAddr - t.rodataAddr))
+	d := t.mod.decoder(t.kindOffset())
+	elemAddr := d.uintptr()
+	sliceAddr := d.uintptr()
+	length := d.uintptr()
+	if d.err != nil {
+		return nil, d.err
+	}
+	elem, err := t.mod.loadTypeByAddr(elemAddr)
 	if err != nil {
 		return nil, err
 	}
-	slice, err := t.loadType(int32(addr.sliceAddr - t.rodataAddr))
+	slice, err := t.mod.loadTypeByAddr(sliceAddr)
 	if err != nil {
 		return nil, err
 	}
 	return &arrayType{
-		rtype: addr.rtype,
+		rtype: t.rtype,
 		elem:  elem,
 		slice: slice,
-		len:   addr.len,
+		len:   length,
 	}, nil
 }
 
 func (t *Type) toPtrType() (*ptrType, error) {
-	var v [54]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+54]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	type ptrAddrType struct {
-		rtype
-		elemAddr uint64
-	}
-	addr := (*ptrAddrType)(unsafe.Pointer(&v))
-	elem, err := t.loadType(int32(addr.elemAddr - t.rodataAddr))
+	elem, err := t.loadElemType()
 	if err != nil {
 		return nil, err
 	}
 	return &ptrType{
-		rtype: addr.rtype,
+		rtype: t.rtype,
 		elem:  elem,
 	}, nil
 }
 
-func (t *Type) toMapType() (*mapType, error) {
-	var v [88]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+88]), t.bo, &v); err != nil {
-		return nil, err
-	}
-	type mapAddrType struct {
-		rtype
-		keyAddr    uint64
-		elemAddr   uint64
-		bucketAddr uint64
-		hasher     func(unsafe.Pointer, uintptr) uintptr
-		keysize    uint8
-		valuesize  uint8
-		bucketsize uint16
-		flags      uint32
-	}
-	addr := (*mapAddrType)(unsafe.Pointer(&v))
-	key, err := t.loadType(int32(addr.keyAddr - t.rodataAddr))
-	if err != nil {
-		return nil, err
-	}
-	elem, err := t.loadType(int32(addr.elemAddr - t.rodataAddr))
-	if err != nil {
-		return nil, err
-	}
-	bucket, err := t.loadType(int32(addr.bucketAddr - t.rodataAddr))
+func (t *Type) toSliceType() (*sliceType, error) {
+	elem, err := t.loadElemType()
 	if err != nil {
 		return nil, err
 	}
-	return &mapType{
-		rtype:      addr.rtype,
-		key:        key,
-		elem:       elem,
-		bucket:     bucket,
-		hasher:     addr.hasher,
-		keysize:    addr.keysize,
-		valuesize:  addr.valuesize,
-		bucketsize: addr.bucketsize,
-		flags:      addr.flags,
+	return &sliceType{
+		rtype: t.rtype,
+		elem:  elem,
 	}, nil
 }
 
-func (t *Type) toSliceType() (*sliceType, error) {
-	var v [54]byte
-	if err := binary.Read(bytes.NewReader(t.rodata[t.offset:t.offset+54]), t.bo, &v); err != nil {
-		return nil, err
+// loadElemType loads elem that is the first field of ptrType and sliceType.
+func (t *Type) loadElemType() (*Type, error) {
+	d := t.mod.decoder(t.kindOffset())
+	elemAddr := d.uintptr()
+	if d.err != nil {
+		return nil, d.err
 	}
-	type sliceAddrType struct {
-		rtype
-		elemAddr uint64
+	return t.mod.loadTypeByAddr(elemAddr)
+}
+
+func (t *Type) toMapType() (*mapType, error) {
+	// key and elem are the first fields of the map type in all layouts.
+	d := t.mod.decoder(t.kindOffset())
+	keyAddr := d.uintptr()
+	elemAddr := d.uintptr()
+	if d.err != nil {
+		return nil, d.err
+	}
+	key, err := t.mod.loadTypeByAddr(keyAddr)
+	if err != nil {
+		return nil, err
 	}
-	addr := (*sliceAddrType)(unsafe.Pointer(&v))
-	elem, err := t.loadType(int32(addr.elemAddr - t.rodataAddr))
+	elem, err := t.mod.loadTypeByAddr(elemAddr)
 	if err != nil {
 		return nil, err
 	}
-	return &sliceType{
-		rtype: addr.rtype,
+	return &mapType{
+		rtype: t.rtype,
+		key:   key,
 		elem:  elem,
 	}, nil
 }
@@ -826,7 +900,7 @@ func (t *Type) Field(i int) reflect.StructField {
 	if err != nil {
 		panic(err)
 	}
-	return tt.Field(i)
+	return tt.Field(i, t)
 }
 
 func (t *Type) FieldByIndex(index []int) reflect.StructField {
@@ -848,7 +922,7 @@ func (t *Type) FieldByName(name string) (reflect.StructField, bool) {
 	if err != nil {
 		panic(err)
 	}
-	return tt.FieldByName(name)
+	return tt.FieldByName(name, t)
 }
 
 func (t *Type) FieldByNameFunc(match func(string) bool) (reflect.StructField, bool) {
@@ -859,7 +933,7 @@ func (t *Type) FieldByNameFunc(match func(string) bool) (reflect.StructField, bo
 	if err != nil {
 		panic(err)
 	}
-	return tt.FieldByNameFunc(match)
+	return tt.FieldByNameFunc(match, t)
 }
 
 func (t *Type) In(i int) reflect.Type {
@@ -951,46 +1025,13 @@ func (t *Type) Out(i int) reflect.Type {
 	return outTypes[i]
 }
 
-func (t *Type) toType(typ *rtype, offset int32) *Type {
-	if typ == nil {
-		return nil
-	}
-	return &Type{
-		rtype:      typ,
-		offset:     offset,
-		rodataAddr: t.rodataAddr,
-		rodata:     t.rodata,
-		bo:         t.bo,
-	}
-}
-
 func (t *Type) ptrTo() (*Type, error) {
 	if t.ptrToThis != 0 {
-		return t.loadType(int32(t.ptrToThis))
+		return t.mod.loadType(int64(t.ptrToThis))
 	}
 	return nil, nil
 }
 
-func nameOffToHeader(name nameOff, data []byte, bo binary.ByteOrder) (byte, error) {
-	var hdr byte
-	if err := binary.Read(bytes.NewReader(data[name:name+1]), bo, &hdr); err != nil {
-		return 0, err
-	}
-	return hdr, nil
-}
-
-func nameOffToText(name nameOff, data []byte, bo binary.ByteOrder) (string, error) {
-	var hdr [4]byte
-	if err := binary.Read(bytes.NewReader(data[name:name+4]), bo, &hdr); err != nil {
-		return "", err
-	}
-	var text string
-	textHeader := (*sliceHeader)(unsafe.Pointer(&text))
-	textHeader.data = unsafe.Pointer(&data[name+2])
-	textHeader.len = int(hdr[1])
-	return text, nil
-}
-
 func (t *interfaceType) NumMethod() int { return len(t.methods) }
 
 func (t *interfaceType) Method(i int, typ *Type) (m reflect.Method) {
@@ -998,22 +1039,26 @@ func (t *interfaceType) Method(i int, typ *Type) (m reflect.Method) {
 		return
 	}
 	p := &t.methods[i]
-	pname, err := nameOffToText(p.name, typ.rodata, typ.bo)
+	pname, err := typ.mod.readName(p.name)
 	if err != nil {
 		panic(err)
 	}
-	m.Name = pname
-	nameHeader, err := nameOffToHeader(p.name, typ.rodata, typ.bo)
-	if err != nil {
-		panic(err)
-	}
-	if !isExported(nameHeader) {
-		m.PkgPath = "" //pname.pkgPath()
-		if m.PkgPath == "" {
-			m.PkgPath = "" //t.pkgPath.name()
+	m.Name = pname.text
+	if !pname.isExported() {
+		pkgPath, err := typ.mod.namePkgPath(pname)
+		if err != nil {
+			panic(err)
+		}
+		m.PkgPath = pkgPath
+		if m.PkgPath == "" && t.pkgPath != 0 {
+			pkgPath, err := typ.mod.nameText(t.pkgPath)
+			if err != nil {
+				panic(err)
+			}
+			m.PkgPath = pkgPath
 		}
 	}
-	tt, err := typ.loadType(int32(p.typ))
+	tt, err := typ.mod.loadType(int64(p.typ))
 	if err != nil {
 		panic(err)
 	}
@@ -1022,14 +1067,6 @@ func (t *interfaceType) Method(i int, typ *Type) (m reflect.Method) {
 	return
 }
 
-func isExported(hdr byte) bool {
-	return hdr&(1<<0) != 0
-}
-
-func hasTag(hdr byte) bool {
-	return hdr&(1<<1) != 0
-}
-
 func (t *interfaceType) MethodByName(name string, typ *Type) (m reflect.Method, ok bool) {
 	if t == nil {
 		return
@@ -1037,7 +1074,7 @@ func (t *interfaceType) MethodByName(name string, typ *Type) (m reflect.Method,
 	var p *imethod
 	for i := range t.methods {
 		p = &t.methods[i]
-		text, err := nameOffToText(p.name, typ.rodata, typ.bo)
+		text, err := typ.mod.nameText(p.name)
 		if err != nil {
 			panic(err)
 		}
@@ -1048,73 +1085,79 @@ func (t *interfaceType) MethodByName(name string, typ *Type) (m reflect.Method,
 	return
 }
 
-func (t *funcType) in(typ *Type) ([]*Type, error) {
-	var uadd uintptr
+// params returns the offset of the parameter types that follow funcType ( and uncommonType ).
+func (t *funcType) params(typ *Type) int64 {
+	offset := int64(typ.offset) + int64(typ.mod.layout.kindTypeSize(reflect.Func))
 	if t.tflag&tflagUncommon != 0 {
-		uadd += unsafe.Sizeof(uncommonType{})
+		offset += int64(typ.mod.layout.uncommonTypeSize())
 	}
+	return offset
+}
+
+func (t *funcType) in(typ *Type) ([]*Type, error) {
 	if t.inCount == 0 {
 		return nil, nil
 	}
-	intypes := make([]*Type, t.inCount)
-	start := uintptr(typ.offset) + uintptr(funcTypeSize) + uadd
-	end := start + 8
-	for i := 0; i < int(t.inCount); i++ {
-		var addr uint64
-		if err := binary.Read(bytes.NewReader(typ.rodata[start:end]), typ.bo, &addr); err != nil {
-			return nil, err
-		}
-		offset := int32(addr - typ.rodataAddr)
-		intype, err := typ.loadType(offset)
-		if err != nil {
-			return nil, err
-		}
-		intypes[i] = intype
-		start += 8
-		end += 8
-	}
-	return intypes, nil
+	return t.loadParams(typ, t.params(typ), int(t.inCount))
 }
 
 func (t *funcType) out(typ *Type) ([]*Type, error) {
-	var uadd uintptr
-	if t.tflag&tflagUncommon != 0 {
-		uadd += unsafe.Sizeof(uncommonType{})
-	}
 	outCount := t.outCount & (1<<15 - 1)
 	if outCount == 0 {
 		return nil, nil
 	}
-	outtypes := make([]*Type, outCount)
-	start := uintptr(typ.offset) + uintptr(funcTypeSize) + uadd + 8*uintptr(t.inCount)
-	end := start + 8
-	for i := 0; i < int(outCount); i++ {
-		var addr uint64
-		if err := binary.Read(bytes.NewReader(typ.rodata[start:end]), typ.bo, &addr); err != nil {
-			return nil, err
+	offset := t.params(typ) + int64(t.inCount)*int64(typ.mod.layout.ptrSize)
+	return t.loadParams(typ, offset, int(outCount))
+}
+
+func (t *funcType) loadParams(typ *Type, offset int64, n int) ([]*Type, error) {
+	d := typ.mod.decoder(offset)
+	types := make([]*Type, n)
+	for i := 0; i < n; i++ {
+		addr := d.uintptr()
+		if d.err != nil {
+			return nil, d.err
 		}
-		offset := int32(addr - typ.rodataAddr)
-		outtype, err := typ.loadType(offset)
+		param, err := typ.mod.loadTypeByAddr(addr)
 		if err != nil {
 			return nil, err
 		}
-		outtypes[i] = outtype
-		start += 8
-		end += 8
+		types[i] = param
 	}
-	return outtypes, nil
+	return types, nil
 }
 
-func (t *structType) Field(i int) (f reflect.StructField) {
+func (t *structType) Field(i int, typ *Type) (f reflect.StructField) {
 	if i < 0 || i >= len(t.fields) {
 		panic("reflect: Field index out of bounds")
 	}
+	p := &t.fields[i]
+	pname, err := typ.mod.readName(p.name)
+	if err != nil {
+		panic(err)
+	}
+	f.Name = pname.text
+	if !pname.isExported() && t.pkgPath != 0 {
+		pkgPath, err := typ.mod.nameText(t.pkgPath)
+		if err != nil {
+			panic(err)
+		}
+		f.PkgPath = pkgPath
+	}
+	if pname.hasTag() {
+		f.Tag = reflect.StructTag(pname.tag)
+	}
+	if p.typ != nil {
+		f.Type = p.typ
+	}
+	f.Offset = uintptr(p.offset)
+	f.Index = []int{i}
+	f.Anonymous = p.embedded
 	return
 }
 
 func (t *structType) FieldByIndex(index []int, typ *Type) (f reflect.StructField) {
-	offset := uintptr(unsafe.Pointer(&t.rtype)) - uintptr(typ.rodataAddr)
-	f.Type = reflect.Type(typ.toType(&t.rtype, int32(offset)))
+	f.Type = reflect.Type(typ)
 	for i, x := range index {
 		if i > 0 {
 			ft := f.Type
@@ -1128,11 +1171,119 @@ func (t *structType) FieldByIndex(index []int, typ *Type) (f reflect.StructField
 	return
 }
 
-func (t *structType) FieldByName(name string) (f reflect.StructField, present bool) {
-	return
+// FieldByName returns the struct field with the given name.
+// Fields promoted through embedded structs are also found in the same way as the reflect package.
+func (t *structType) FieldByName(name string, typ *Type) (f reflect.StructField, present bool) {
+	hasEmbeds := false
+	if name != "" {
+		for i := range t.fields {
+			tf := &t.fields[i]
+			tname, err := typ.mod.nameText(tf.name)
+			if err != nil {
+				panic(err)
+			}
+			if tname == name {
+				return t.Field(i, typ), true
+			}
+			if tf.embedded {
+				hasEmbeds = true
+			}
+		}
+	}
+	if !hasEmbeds {
+		return
+	}
+	return t.FieldByNameFunc(func(s string) bool { return s == name }, typ)
+}
+
+// fieldScan is a struct type to scan for the promoted fields and its index sequence from the outermost struct.
+type fieldScan struct {
+	typ   *Type
+	index []int
 }
 
-func (t *structType) FieldByNameFunc(match func(string) bool) (result reflect.StructField, ok bool) {
+// FieldByNameFunc searches the struct fields in breadth first order of the embedding depth.
+// As in the reflect package, if multiple fields match at the same depth, it returns not found.
+func (t *structType) FieldByNameFunc(match func(string) bool, typ *Type) (result reflect.StructField, ok bool) {
+	current := []fieldScan{}
+	next := []fieldScan{{typ: typ}}
+
+	// nextCount records the number of times an embedded type has been encountered and considered for queueing in the next slice.
+	// It is keyed by the offset of the type because types are decoded on each access.
+	var nextCount map[int32]int
+
+	visited := map[int32]bool{}
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count := nextCount
+		nextCount = nil
+
+		for _, scan := range current {
+			styp := scan.typ
+			if visited[styp.offset] {
+				continue
+			}
+			visited[styp.offset] = true
+			st, err := styp.toStructType()
+			if err != nil {
+				panic(err)
+			}
+			for i := range st.fields {
+				f := &st.fields[i]
+				fname, err := styp.mod.nameText(f.name)
+				if err != nil {
+					panic(err)
+				}
+				var ntyp *Type
+				if f.embedded {
+					ntyp = f.typ
+					if ntyp != nil && ntyp.Kind() == reflect.Ptr {
+						elem, err := ntyp.loadElemType()
+						if err != nil {
+							panic(err)
+						}
+						ntyp = elem
+					}
+				}
+
+				if match(fname) {
+					if count[styp.offset] > 1 || ok {
+						// name appeared multiple times at this level: annihilate.
+						return reflect.StructField{}, false
+					}
+					result = st.Field(i, styp)
+					result.Index = nil
+					result.Index = append(result.Index, scan.index...)
+					result.Index = append(result.Index, i)
+					ok = true
+					continue
+				}
+
+				if ok || ntyp == nil || ntyp.Kind() != reflect.Struct {
+					continue
+				}
+				if nextCount[ntyp.offset] > 0 {
+					nextCount[ntyp.offset] = 2 // exact multiple doesn't matter
+					continue
+				}
+				if nextCount == nil {
+					nextCount = map[int32]int{}
+				}
+				nextCount[ntyp.offset] = 1
+				if count[styp.offset] > 1 {
+					nextCount[ntyp.offset] = 2 // exact multiple doesn't matter
+				}
+				var index []int
+				index = append(index, scan.index...)
+				index = append(index, i)
+				next = append(next, fieldScan{typ: ntyp, index: index})
+			}
+		}
+		if ok {
+			break
+		}
+	}
 	return
 }
 
@@ -1141,5 +1292,8 @@ func PtrTo(t reflect.Type) reflect.Type {
 	if err != nil {
 		panic(err)
 	}
+	if tt == nil {
+		return nil
+	}
 	return tt
 }
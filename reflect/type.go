@@ -34,12 +34,19 @@ const (
 	UnsafePointer = reflect.UnsafePointer
 )
 
+const (
+	RecvDir = reflect.RecvDir
+	SendDir = reflect.SendDir
+	BothDir = reflect.BothDir
+)
+
 type Type interface {
 	Align() int
 	FieldAlign() int
 	Method(int) Method
 	MethodByName(string) (Method, bool)
 	NumMethod() int
+	AllMethods() []Method
 	Name() string
 	PkgPath() string
 	Size() uintptr
@@ -72,6 +79,12 @@ type Method struct {
 	PkgPath string
 	Type    Type
 	Index   int
+	// Ifn is the address of the code called through an interface. Its receiver is always a pointer.
+	// It is 0 for interface methods and the methods removed by the linker.
+	Ifn uintptr
+	// Tfn is the address of the code called by a method call on the type.
+	// It is 0 for interface methods and the methods removed by the linker.
+	Tfn uintptr
 }
 
 type Kind = reflect.Kind
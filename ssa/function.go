@@ -0,0 +1,395 @@
+package ssa
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/ssa"
+)
+
+// Function is the SSA form lifted from the machine code of a function.
+// The registers and the stack slots addressed by SP are the variables of the SSA form,
+// so a value is defined once and Phi merges the values of a variable at the join points of the control flow.
+type Function struct {
+	Name string
+	// Signature is the signature of the function. It has no parameters and results if the binary has no type metadata of the function.
+	Signature *types.Signature
+	// Params is the variables read before they are written, that is, the inputs of the function.
+	// The registers of the register ABI come first in the order of the assignment.
+	Params []*Parameter
+	// Blocks is the basic blocks reachable from the entry in the order of their addresses.
+	// Blocks[0] is the entry and has no predecessors. If the code jumps back to the first instruction
+	// ( e.g. after runtime.morestack ), Blocks[0] is an empty block that jumps to the block of the first instruction.
+	Blocks []*BasicBlock
+	// Args and Results are the arguments and the results of the function in the register ABI.
+	// They are assigned by the signature if it is known. Otherwise they are inferred from the registers read before they are written,
+	// the size of the arguments and the registers computed on every path to the returns, and their types are unknown.
+	Args, Results []*Param
+	argUses       map[uint64][]*Param
+}
+
+// Param is an argument or a result of a function.
+type Param struct {
+	// Name is arg0, arg1, ... for the arguments ( the receiver is arg0 ) and r0, r1, ... for the results.
+	Name string
+	// Type is the type of the value. It is nil if the signature is unknown.
+	Type types.Type
+	// Regs is the registers that hold the value in the order of the assignment. It is empty if the value is passed on the stack.
+	Regs []string
+}
+
+func (p *Param) String() string {
+	s := p.Name
+	if p.Type != nil {
+		s += " " + p.Type.String()
+	}
+	if len(p.Regs) > 0 {
+		s += " (" + strings.Join(p.Regs, ", ") + ")"
+	}
+	return s
+}
+
+// ArgsAt returns the arguments that the machine instruction at pc reads before they are overwritten.
+func (f *Function) ArgsAt(pc uint64) []*Param {
+	return f.argUses[pc]
+}
+
+// BasicBlock is a sequence of instructions that has a single entry and a single exit.
+type BasicBlock struct {
+	Index int
+	// Addr is the address of the first machine instruction of the block.
+	Addr uint64
+	// Instrs is the instructions of the block. Phi nodes come first and the last one is the terminator
+	// ( *Jump, *If, *Return, *IndirectJump or *Trap ).
+	Instrs []Instruction
+	// Preds and Succs are the predecessors and the successors. The edges of Phi are in the order of Preds.
+	// The successors of *If are the blocks for true and false.
+	Preds, Succs []*BasicBlock
+}
+
+// Value is a value of the SSA form.
+type Value interface {
+	// Name returns the name to refer to the value ( e.g. t1, AX ).
+	Name() string
+	// String returns the expression of the value ( e.g. t1 + t2 ).
+	String() string
+	// Type returns the type of the value. It returns nil if the type is unknown.
+	Type() types.Type
+}
+
+// Instruction is an instruction of the SSA form.
+type Instruction interface {
+	String() string
+	// Block returns the basic block that the instruction belongs to.
+	Block() *BasicBlock
+	// PC returns the address of the machine instruction that the instruction is lifted from.
+	PC() uint64
+	// Operands appends the pointers to the operands of the instruction to rands.
+	Operands(rands []*Value) []*Value
+	setBlock(block *BasicBlock, pc uint64)
+}
+
+type anInstruction struct {
+	block *BasicBlock
+	pc    uint64
+}
+
+func (i *anInstruction) Block() *BasicBlock                    { return i.block }
+func (i *anInstruction) PC() uint64                            { return i.pc }
+func (i *anInstruction) setBlock(block *BasicBlock, pc uint64) { i.block, i.pc = block, pc }
+
+// register is the common part of the instructions that define a value.
+type register struct {
+	anInstruction
+	num int
+	typ types.Type
+}
+
+func (r *register) Name() string     { return fmt.Sprintf("t%d", r.num) }
+func (r *register) Type() types.Type { return r.typ }
+func (r *register) reg() *register   { return r }
+
+// Parameter is the value of a variable at the entry of the function.
+type Parameter struct {
+	// Var is the name of the register ( e.g. AX ) or the stack slot ( e.g. SP+8 ).
+	Var string
+	typ types.Type
+}
+
+func (p *Parameter) Name() string     { return p.Var }
+func (p *Parameter) String() string   { return "parameter " + p.Var }
+func (p *Parameter) Type() types.Type { return p.typ }
+
+// Const is an immediate value.
+type Const struct {
+	Value int64
+}
+
+func (c *Const) Name() string     { return c.String() }
+func (c *Const) String() string   { return fmt.Sprint(c.Value) }
+func (c *Const) Type() types.Type { return nil }
+
+// Phi merges the values of Var from the predecessors of the block.
+type Phi struct {
+	register
+	Var   string
+	Edges []Value
+}
+
+func (v *Phi) String() string {
+	var b strings.Builder
+	b.WriteString("phi [")
+	for i, edge := range v.Edges {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%d: %s", v.block.Preds[i].Index, edge.Name())
+	}
+	fmt.Fprintf(&b, "] #%s", v.Var)
+	return b.String()
+}
+
+func (v *Phi) Operands(rands []*Value) []*Value {
+	for i := range v.Edges {
+		rands = append(rands, &v.Edges[i])
+	}
+	return rands
+}
+
+// BinOp is a binary operation. Op is an arithmetic operator or a comparison operator.
+type BinOp struct {
+	register
+	Op   token.Token
+	X, Y Value
+	// Unsigned reports whether the operation is unsigned ( e.g. JB, JA and SHR ).
+	Unsigned bool
+}
+
+func (v *BinOp) String() string {
+	if v.Unsigned {
+		return fmt.Sprintf("%s %s %s (unsigned)", v.X.Name(), v.Op, v.Y.Name())
+	}
+	return fmt.Sprintf("%s %s %s", v.X.Name(), v.Op, v.Y.Name())
+}
+
+func (v *BinOp) Operands(rands []*Value) []*Value { return append(rands, &v.X, &v.Y) }
+
+// UnOp is a unary operation ( - for NEG and ^ for NOT ).
+type UnOp struct {
+	register
+	Op token.Token
+	X  Value
+}
+
+func (v *UnOp) String() string                   { return fmt.Sprintf("%s%s", v.Op, v.X.Name()) }
+func (v *UnOp) Operands(rands []*Value) []*Value { return append(rands, &v.X) }
+
+// Load loads the value from the memory at Addr.
+type Load struct {
+	register
+	Addr Value
+}
+
+func (v *Load) String() string                   { return "*" + v.Addr.Name() }
+func (v *Load) Operands(rands []*Value) []*Value { return append(rands, &v.Addr) }
+
+// Store stores Val into the memory at Addr.
+type Store struct {
+	anInstruction
+	Addr Value
+	Val  Value
+}
+
+func (s *Store) String() string                   { return fmt.Sprintf("*%s = %s", s.Addr.Name(), s.Val.Name()) }
+func (s *Store) Operands(rands []*Value) []*Value { return append(rands, &s.Addr, &s.Val) }
+
+// Call is a function call. The value is the first result.
+type Call struct {
+	register
+	// Addr is the address of the callee of a direct call. It is 0 for an indirect call.
+	Addr uint64
+	// Func is the callee of a direct call. It is nil if the callee is unknown.
+	Func *ssa.Function
+	// Value is the callee of an indirect call ( e.g. the method loaded from an itab ).
+	Value Value
+	// Args is the values in the argument registers. It is empty if the signature of the callee is unknown.
+	Args []Value
+}
+
+func (v *Call) String() string {
+	var b strings.Builder
+	b.WriteString("call ")
+	switch {
+	case v.Value != nil:
+		b.WriteString(v.Value.Name())
+	case v.Func != nil:
+		b.WriteString(v.Func.Name())
+	default:
+		fmt.Fprintf(&b, "%#x", v.Addr)
+	}
+	b.WriteString("(")
+	for i, arg := range v.Args {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(arg.Name())
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (v *Call) Operands(rands []*Value) []*Value {
+	if v.Value != nil {
+		rands = append(rands, &v.Value)
+	}
+	for i := range v.Args {
+		rands = append(rands, &v.Args[i])
+	}
+	return rands
+}
+
+// Extract is the Index-th result register of Call ( Index is 1 or more ).
+type Extract struct {
+	register
+	Call  *Call
+	Index int
+}
+
+func (v *Extract) String() string                   { return fmt.Sprintf("extract %s #%d", v.Call.Name(), v.Index) }
+func (v *Extract) Operands(rands []*Value) []*Value { return rands }
+
+// Opaque is the value computed by a machine instruction that the lifter does not interpret.
+type Opaque struct {
+	register
+	// Op is the mnemonic of the machine instruction.
+	Op   string
+	Args []Value
+}
+
+func (v *Opaque) String() string {
+	args := make([]string, 0, len(v.Args))
+	for _, arg := range v.Args {
+		args = append(args, arg.Name())
+	}
+	return fmt.Sprintf("opaque %s(%s)", v.Op, strings.Join(args, ", "))
+}
+
+func (v *Opaque) Operands(rands []*Value) []*Value {
+	for i := range v.Args {
+		rands = append(rands, &v.Args[i])
+	}
+	return rands
+}
+
+// Jump jumps to the single successor.
+type Jump struct{ anInstruction }
+
+func (j *Jump) String() string                   { return fmt.Sprintf("jump %d", j.block.Succs[0].Index) }
+func (j *Jump) Operands(rands []*Value) []*Value { return rands }
+
+// If jumps to Succs[0] if Cond is true, otherwise to Succs[1].
+type If struct {
+	anInstruction
+	Cond Value
+}
+
+func (i *If) String() string {
+	return fmt.Sprintf("if %s goto %d else %d", i.Cond.Name(), i.block.Succs[0].Index, i.block.Succs[1].Index)
+}
+
+func (i *If) Operands(rands []*Value) []*Value { return append(rands, &i.Cond) }
+
+// Return returns from the function. Results is the values in the result registers if the signature is known.
+type Return struct {
+	anInstruction
+	Results []Value
+}
+
+func (r *Return) String() string {
+	results := make([]string, 0, len(r.Results))
+	for _, result := range r.Results {
+		results = append(results, result.Name())
+	}
+	return strings.TrimSpace("return " + strings.Join(results, ", "))
+}
+
+func (r *Return) Operands(rands []*Value) []*Value {
+	for i := range r.Results {
+		rands = append(rands, &r.Results[i])
+	}
+	return rands
+}
+
+// IndirectJump jumps to the address computed at run time. The successors of the block are the destinations
+// if the jump table of a switch statement is resolved by WithMemory. Otherwise the destinations are unknown.
+type IndirectJump struct {
+	anInstruction
+	Target Value
+}
+
+func (j *IndirectJump) String() string                   { return "jump " + j.Target.Name() }
+func (j *IndirectJump) Operands(rands []*Value) []*Value { return append(rands, &j.Target) }
+
+// Trap stops the execution ( e.g. INT3 and UD2 ).
+// Op is empty after the calls of the functions that never return ( e.g. runtime.gopanic ) and at the end of the machine code without a terminator.
+type Trap struct {
+	anInstruction
+	Op string
+}
+
+func (t *Trap) String() string                   { return strings.TrimSpace("trap " + t.Op) }
+func (t *Trap) Operands(rands []*Value) []*Value { return rands }
+
+func (f *Function) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Name: %s\n", f.Name)
+	if f.Signature != nil {
+		fmt.Fprintf(&b, "# Signature: %s\n", f.Signature)
+	}
+	if len(f.Params) > 0 {
+		params := make([]string, 0, len(f.Params))
+		for _, p := range f.Params {
+			if p.Type() != nil {
+				params = append(params, fmt.Sprintf("%s %s", p.Name(), p.Type()))
+			} else {
+				params = append(params, p.Name())
+			}
+		}
+		fmt.Fprintf(&b, "# Params: %s\n", strings.Join(params, ", "))
+	}
+	for _, list := range []struct {
+		name   string
+		params []*Param
+	}{{"Args", f.Args}, {"Results", f.Results}} {
+		if len(list.params) == 0 {
+			continue
+		}
+		params := make([]string, 0, len(list.params))
+		for _, p := range list.params {
+			params = append(params, p.String())
+		}
+		fmt.Fprintf(&b, "# %s: %s\n", list.name, strings.Join(params, ", "))
+	}
+	for _, block := range f.Blocks {
+		fmt.Fprintf(&b, "%d: %#x", block.Index, block.Addr)
+		if len(block.Preds) > 0 {
+			preds := make([]string, 0, len(block.Preds))
+			for _, pred := range block.Preds {
+				preds = append(preds, fmt.Sprint(pred.Index))
+			}
+			fmt.Fprintf(&b, " ; preds %s", strings.Join(preds, " "))
+		}
+		b.WriteString("\n")
+		for _, instr := range block.Instrs {
+			if v, ok := instr.(Value); ok {
+				fmt.Fprintf(&b, "\t%s = %s\n", v.Name(), v.String())
+			} else {
+				fmt.Fprintf(&b, "\t%s\n", instr.String())
+			}
+		}
+	}
+	return b.String()
+}
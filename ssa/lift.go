@@ -0,0 +1,586 @@
+package ssa
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/arch"
+	"golang.org/x/tools/go/ssa"
+)
+
+// LiftOption is an option of Lift.
+type LiftOption func(*liftConfig)
+
+type liftConfig struct {
+	argsSize int
+	abi0     bool
+	read     arch.ReadMemory
+}
+
+// WithArgsSize gives the size of the arguments in the funcdata of the function.
+// It decides the number of the arguments if the signature is unknown.
+func WithArgsSize(size int) LiftOption {
+	return func(cfg *liftConfig) {
+		cfg.argsSize = size
+	}
+}
+
+// WithABI0 tells that the function passes the arguments and the results on the stack.
+// Go uses the register ABI since Go 1.17 on amd64, and the stack on 386.
+func WithABI0() LiftOption {
+	return func(cfg *liftConfig) {
+		cfg.abi0 = true
+	}
+}
+
+// WithMemory gives the memory of the binary to resolve the jump tables of the switch statements.
+func WithMemory(read arch.ReadMemory) LiftOption {
+	return func(cfg *liftConfig) {
+		cfg.read = read
+	}
+}
+
+// CalleeLookup returns the function whose entry is addr. It returns nil if addr is not the entry of a function.
+type CalleeLookup func(addr uint64) *ssa.Function
+
+// Lift lifts the machine code of a function into SSA form.
+// insts is the decoded instructions of the function from its entry to end, and sig is its signature if it is known.
+// callee resolves the callee of direct calls, and its signature decides the argument and result registers.
+// The SSA form is constructed by the algorithm of Braun et al. ( Simple and Efficient Construction of Static Single Assignment Form ).
+func Lift(arc arch.Arch, name string, sig *types.Signature, insts []*arch.Inst, end uint64, callee CalleeLookup, opts ...LiftOption) (*Function, error) {
+	cfg := &liftConfig{argsSize: -1}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if len(insts) == 0 {
+		return nil, fmt.Errorf("failed to lift %s: no instructions", name)
+	}
+	var m machine
+	switch arc.Name() {
+	case "amd64":
+		m = &x86Machine{mode: 64, abi0: cfg.abi0}
+	case "386":
+		m = &x86Machine{mode: 32}
+	default:
+		return nil, fmt.Errorf("failed to lift %s: unsupported architecture %s", name, arc.Name())
+	}
+	graphOpts := []binarycfg.Option{binarycfg.WithNoReturn(func(addr uint64) bool {
+		return callee != nil && isNoReturnFunc(callee(addr))
+	})}
+	if cfg.read != nil {
+		graphOpts = append(graphOpts, binarycfg.WithMemory(cfg.read))
+	}
+	graph, err := binarycfg.Build(arc, insts, end, graphOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lift %s: %w", name, err)
+	}
+	if sig == nil {
+		sig = types.NewSignature(nil, nil, nil, false)
+	}
+	l := &lifter{
+		fn:         &Function{Name: name, Signature: sig},
+		arc:        arc,
+		machine:    m,
+		callee:     callee,
+		vars:       map[string]int{},
+		params:     map[string]*Parameter{},
+		paramTypes: m.paramTypes(sig),
+		replaced:   map[*Phi]Value{},
+	}
+	l.buildBlocks(graph)
+	l.lift()
+	l.fn.Args, l.fn.Results = m.abiParams(l, cfg.argsSize)
+	l.fn.argUses = l.argUses()
+	return l.fn, nil
+}
+
+// machine interprets the machine instructions of an architecture.
+type machine interface {
+	// liftInst appends the SSA instructions of inst to the current block of l.
+	liftInst(l *lifter, inst *arch.Inst)
+	// liftTerminator appends the terminator of the current block of l that ends with inst.
+	liftTerminator(l *lifter, inst *arch.Inst, kind binarycfg.Kind)
+	// paramOrder returns the order of the variable in Function.Params.
+	paramOrder(v string) int
+	// paramTypes returns the types of the variables that hold a parameter of sig alone.
+	paramTypes(sig *types.Signature) map[string]types.Type
+	// abiParams returns the arguments and the results of the function lifted by l.
+	abiParams(l *lifter, argsSize int) (args, results []*Param)
+}
+
+// isNoReturnFunc reports whether fn never returns. The compiler places the next block right after the calls of such functions.
+func isNoReturnFunc(fn *ssa.Function) bool {
+	if fn == nil || fn.Pkg == nil {
+		return false
+	}
+	// BuildFunction names the package by the import path in the symbol.
+	return binarycfg.NoReturnFunc(fn.Pkg.Pkg.Name() + "." + fn.Name())
+}
+
+type variableUse struct {
+	pc    uint64
+	value Value
+}
+
+type blockInsts struct {
+	block *BasicBlock
+	insts []*arch.Inst
+	kind  binarycfg.Kind
+}
+
+type lifter struct {
+	fn      *Function
+	arc     arch.Arch
+	machine machine
+	callee  CalleeLookup
+	blocks  []*blockInsts
+	// cur and pc are the block and the address of the machine instruction being lifted.
+	cur *BasicBlock
+	pc  uint64
+	// uses is the variables read by the machine instructions.
+	uses []variableUse
+
+	// vars numbers the variables. currentDef[block index][id] is the value of the variable at the end of the block.
+	vars       map[string]int
+	varNames   []string
+	currentDef [][]Value
+	sealed     []bool
+	filled     []bool
+	incomplete [][]*Phi
+	phis       [][]*Phi
+	params     map[string]*Parameter
+	paramTypes map[string]types.Type
+	// replaced is the value of the trivial phi nodes removed.
+	replaced map[*Phi]Value
+}
+
+// buildBlocks builds the basic blocks of the control-flow graph reachable from the entry.
+func (l *lifter) buildBlocks(graph *binarycfg.Graph) {
+	reachable := make([]bool, len(graph.Blocks))
+	var visit func(b *binarycfg.Block)
+	visit = func(b *binarycfg.Block) {
+		reachable[b.Index] = true
+		for _, succ := range b.Succs {
+			if !reachable[succ.Index] {
+				visit(succ)
+			}
+		}
+	}
+	visit(graph.Entry)
+	add := func(b *blockInsts) {
+		b.block.Index = len(l.blocks)
+		l.blocks = append(l.blocks, b)
+		l.fn.Blocks = append(l.fn.Blocks, b.block)
+	}
+	for _, pred := range graph.Entry.Preds {
+		if reachable[pred.Index] {
+			// the entry must have no predecessors to define the parameters there.
+			add(&blockInsts{block: &BasicBlock{Addr: graph.Entry.Addr}, kind: binarycfg.Jump})
+			break
+		}
+	}
+	blockOf := make([]*blockInsts, len(graph.Blocks))
+	for _, b := range graph.Blocks {
+		if reachable[b.Index] {
+			blockOf[b.Index] = &blockInsts{block: &BasicBlock{Addr: b.Addr}, insts: b.Insts, kind: b.Kind}
+			add(blockOf[b.Index])
+		}
+	}
+	link := func(from, to *BasicBlock) {
+		from.Succs = append(from.Succs, to)
+		to.Preds = append(to.Preds, from)
+	}
+	if entry := blockOf[graph.Entry.Index]; l.blocks[0] != entry {
+		link(l.blocks[0].block, entry.block)
+	}
+	for _, b := range graph.Blocks {
+		if !reachable[b.Index] {
+			continue
+		}
+		for _, succ := range b.Succs {
+			link(blockOf[b.Index].block, blockOf[succ.Index].block)
+		}
+	}
+}
+
+func (l *lifter) lift() {
+	n := len(l.blocks)
+	l.sealed, l.filled = make([]bool, n), make([]bool, n)
+	l.incomplete, l.phis = make([][]*Phi, n), make([][]*Phi, n)
+	l.currentDef = make([][]Value, n)
+	for _, b := range l.reversePostorder() {
+		l.cur = b.block
+		if len(b.block.Preds) == 0 {
+			l.sealed[b.block.Index] = true
+		}
+		l.liftBlock(b)
+		l.filled[b.block.Index] = true
+		// only the successors can have all the predecessors filled now.
+		for _, succ := range b.block.Succs {
+			if !l.sealed[succ.Index] && l.allPredsFilled(succ) {
+				l.sealBlock(succ)
+			}
+		}
+	}
+	l.finish()
+}
+
+func (l *lifter) liftBlock(b *blockInsts) {
+	if len(b.insts) == 0 {
+		l.emit(&Jump{}, b.block.Addr)
+		return
+	}
+	last := b.insts[len(b.insts)-1]
+	for _, inst := range b.insts[:len(b.insts)-1] {
+		l.pc = inst.PC
+		l.machine.liftInst(l, inst)
+	}
+	l.pc = last.PC
+	switch b.kind {
+	case binarycfg.Plain:
+		l.machine.liftInst(l, last)
+		// falls through to the next block.
+		l.emit(&Jump{}, last.PC)
+	case binarycfg.Exit:
+		l.machine.liftInst(l, last)
+		l.emit(&Trap{}, last.PC)
+	default:
+		l.machine.liftTerminator(l, last, b.kind)
+	}
+}
+
+func (l *lifter) reversePostorder() []*blockInsts {
+	var (
+		order   = make([]*blockInsts, 0, len(l.blocks))
+		visited = make([]bool, len(l.blocks))
+		visit   func(b *BasicBlock)
+	)
+	visit = func(b *BasicBlock) {
+		visited[b.Index] = true
+		for _, succ := range b.Succs {
+			if !visited[succ.Index] {
+				visit(succ)
+			}
+		}
+		order = append(order, l.blocks[b.Index])
+	}
+	visit(l.fn.Blocks[0])
+	for i, j := 0, len(order)-1; i < j; i, j = i+1, j-1 {
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+func (l *lifter) allPredsFilled(b *BasicBlock) bool {
+	for _, pred := range b.Preds {
+		if !l.filled[pred.Index] {
+			return false
+		}
+	}
+	return true
+}
+
+// emit appends instr to the current block.
+func (l *lifter) emit(instr Instruction, pc uint64) {
+	instr.setBlock(l.cur, pc)
+	l.cur.Instrs = append(l.cur.Instrs, instr)
+}
+
+// read returns the current value of the variable v read by the machine instruction.
+func (l *lifter) read(v string) Value {
+	value := l.readVariable(l.varID(v), l.cur)
+	l.uses = append(l.uses, variableUse{pc: l.pc, value: value})
+	return value
+}
+
+// write assigns value to the variable v.
+func (l *lifter) write(v string, value Value) {
+	l.writeVariable(l.varID(v), l.cur, value)
+}
+
+// varID returns the number of the variable v.
+func (l *lifter) varID(v string) int {
+	if id, exists := l.vars[v]; exists {
+		return id
+	}
+	id := len(l.varNames)
+	l.vars[v] = id
+	l.varNames = append(l.varNames, v)
+	return id
+}
+
+func (l *lifter) writeVariable(id int, block *BasicBlock, value Value) {
+	defs := l.currentDef[block.Index]
+	if id >= len(defs) {
+		defs = append(defs, make([]Value, id+1-len(defs))...)
+		l.currentDef[block.Index] = defs
+	}
+	defs[id] = value
+}
+
+// currentValue returns the value of the variable defined in block. It returns nil if the variable is not defined in block yet.
+func (l *lifter) currentValue(id int, block *BasicBlock) Value {
+	if defs := l.currentDef[block.Index]; id < len(defs) {
+		return defs[id]
+	}
+	return nil
+}
+
+func (l *lifter) readVariable(id int, block *BasicBlock) Value {
+	if value := l.currentValue(id, block); value != nil {
+		return value
+	}
+	var value Value
+	switch {
+	case !l.sealed[block.Index]:
+		phi := l.newPhi(id, block)
+		l.incomplete[block.Index] = append(l.incomplete[block.Index], phi)
+		value = phi
+	case len(block.Preds) == 0:
+		value = l.param(l.varNames[id])
+	case len(block.Preds) == 1:
+		value = l.readVariable(id, block.Preds[0])
+	default:
+		phi := l.newPhi(id, block)
+		// define the phi before reading the operands to break the cycles of the loops.
+		l.writeVariable(id, block, phi)
+		l.addPhiOperands(id, phi)
+		value = phi
+	}
+	l.writeVariable(id, block, value)
+	return value
+}
+
+func (l *lifter) newPhi(id int, block *BasicBlock) *Phi {
+	phi := &Phi{Var: l.varNames[id]}
+	phi.setBlock(block, block.Addr)
+	l.phis[block.Index] = append(l.phis[block.Index], phi)
+	return phi
+}
+
+func (l *lifter) addPhiOperands(id int, phi *Phi) {
+	for _, pred := range phi.block.Preds {
+		phi.Edges = append(phi.Edges, l.readVariable(id, pred))
+	}
+}
+
+func (l *lifter) sealBlock(block *BasicBlock) {
+	l.sealed[block.Index] = true
+	for _, phi := range l.incomplete[block.Index] {
+		l.addPhiOperands(l.vars[phi.Var], phi)
+	}
+	l.incomplete[block.Index] = nil
+}
+
+func (l *lifter) param(v string) *Parameter {
+	if p, exists := l.params[v]; exists {
+		return p
+	}
+	p := &Parameter{Var: v, typ: l.paramTypes[v]}
+	l.params[v] = p
+	return p
+}
+
+// resolve returns the value that replaces v if v is a removed phi.
+func (l *lifter) resolve(v Value) Value {
+	for {
+		phi, ok := v.(*Phi)
+		if !ok {
+			return v
+		}
+		replacement, exists := l.replaced[phi]
+		if !exists {
+			return v
+		}
+		v = replacement
+	}
+}
+
+// finish removes the trivial phi nodes, places the phi nodes at the head of the blocks and numbers the values.
+func (l *lifter) finish() {
+	for changed := true; changed; {
+		changed = false
+		for _, b := range l.fn.Blocks {
+			for _, phi := range l.phis[b.Index] {
+				if _, removed := l.replaced[phi]; removed {
+					continue
+				}
+				var same Value
+				trivial := true
+				for _, edge := range phi.Edges {
+					edge = l.resolve(edge)
+					if edge == same || edge == Value(phi) {
+						continue
+					}
+					if same != nil {
+						trivial = false
+						break
+					}
+					same = edge
+				}
+				if !trivial {
+					continue
+				}
+				if same == nil {
+					// the variable is never defined on any path.
+					same = l.param(phi.Var)
+				}
+				l.replaced[phi] = same
+				changed = true
+			}
+		}
+	}
+	num := 0
+	for _, b := range l.fn.Blocks {
+		instrs := make([]Instruction, 0, len(l.phis[b.Index])+len(b.Instrs))
+		for _, phi := range l.phis[b.Index] {
+			if _, removed := l.replaced[phi]; !removed {
+				instrs = append(instrs, phi)
+			}
+		}
+		b.Instrs = append(instrs, b.Instrs...)
+		for _, instr := range b.Instrs {
+			for _, rand := range instr.Operands(nil) {
+				*rand = l.resolve(*rand)
+			}
+			if r := registerOf(instr); r != nil {
+				r.num = num
+				num++
+			}
+		}
+	}
+	// the phi nodes of the loops depend on each other.
+	for changed := true; changed; {
+		changed = false
+		for _, b := range l.fn.Blocks {
+			for _, instr := range b.Instrs {
+				if phi, ok := instr.(*Phi); ok && phi.typ == nil {
+					if phi.typ = phiType(phi); phi.typ != nil {
+						changed = true
+					}
+				}
+			}
+		}
+	}
+	for _, p := range l.params {
+		l.fn.Params = append(l.fn.Params, p)
+	}
+	sort.Slice(l.fn.Params, func(i, j int) bool {
+		oi, oj := l.machine.paramOrder(l.fn.Params[i].Var), l.machine.paramOrder(l.fn.Params[j].Var)
+		if oi != oj {
+			return oi < oj
+		}
+		return l.fn.Params[i].Var < l.fn.Params[j].Var
+	})
+}
+
+func registerOf(instr Instruction) *register {
+	if r, ok := instr.(interface{ reg() *register }); ok {
+		return r.reg()
+	}
+	return nil
+}
+
+// phiType returns the type of the edges if all of them have the same type.
+func phiType(phi *Phi) types.Type {
+	var typ types.Type
+	for _, edge := range phi.Edges {
+		if edge == Value(phi) {
+			continue
+		}
+		t := edge.Type()
+		if t == nil || (typ != nil && !types.Identical(typ, t)) {
+			return nil
+		}
+		typ = t
+	}
+	return typ
+}
+
+// returnsComputed reports whether the function computes the value of the variable v on every path to the returns.
+// The values of the parameters and the values left by the calls are not computed by the function.
+func (l *lifter) returnsComputed(v string) bool {
+	id, exists := l.vars[v]
+	if !exists {
+		return false
+	}
+	// computed[i] is whether v is computed at the end of the block i. It is solved from the optimistic assumption for the loops.
+	computed := make([]bool, len(l.fn.Blocks))
+	for i := range computed {
+		computed[i] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, b := range l.fn.Blocks {
+			if computed[b.Index] && !l.computedAt(id, b, computed) {
+				computed[b.Index] = false
+				changed = true
+			}
+		}
+	}
+	var returns bool
+	for _, b := range l.fn.Blocks {
+		if _, ok := b.Instrs[len(b.Instrs)-1].(*Return); !ok {
+			continue
+		}
+		if !computed[b.Index] {
+			return false
+		}
+		returns = true
+	}
+	return returns
+}
+
+func (l *lifter) computedAt(id int, b *BasicBlock, computed []bool) bool {
+	preds := b.Preds
+	if value := l.currentValue(id, b); value != nil {
+		switch value := l.resolve(value).(type) {
+		case *Parameter, *Call, *Extract:
+			return false
+		case *Phi:
+			preds = value.block.Preds
+		default:
+			return true
+		}
+	} else if len(preds) == 0 {
+		return false
+	}
+	for _, pred := range preds {
+		if !computed[pred.Index] {
+			return false
+		}
+	}
+	return true
+}
+
+// argUses returns the arguments read by each machine instruction.
+func (l *lifter) argUses() map[uint64][]*Param {
+	byReg := map[string]*Param{}
+	for _, arg := range l.fn.Args {
+		for _, reg := range arg.Regs {
+			byReg[reg] = arg
+		}
+	}
+	uses := map[uint64][]*Param{}
+	for _, use := range l.uses {
+		p, ok := l.resolve(use.value).(*Parameter)
+		if !ok || byReg[p.Var] == nil {
+			continue
+		}
+		arg := byReg[p.Var]
+		if args := uses[use.pc]; len(args) == 0 || args[len(args)-1] != arg {
+			uses[use.pc] = append(args, arg)
+		}
+	}
+	return uses
+}
+
+func newParams(prefix string, vars []*types.Var, regs [][]string) []*Param {
+	params := make([]*Param, 0, len(vars))
+	for i, v := range vars {
+		params = append(params, &Param{Name: fmt.Sprintf("%s%d", prefix, i), Type: v.Type(), Regs: regs[i]})
+	}
+	return params
+}
@@ -0,0 +1,592 @@
+package ssa
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+
+	binarycfg "github.com/goccy/binarian/cfg"
+	"github.com/goccy/binarian/internal/arch"
+	"golang.org/x/arch/x86/x86asm"
+	"golang.org/x/tools/go/ssa"
+)
+
+// x86Machine lifts the machine code of 386 and amd64.
+//
+// The general purpose registers are the variables named in Go assembler style ( e.g. AX, R8 ), and the writes to
+// their lower parts are regarded as the writes to the whole registers. The memory addressed by SP without an index is the stack slot.
+// The arguments and the results of the register ABI of amd64 ( ABIInternal ) are recovered from the signatures.
+type x86Machine struct {
+	mode int
+	// abi0 reports whether the arguments are passed on the stack on amd64 ( before Go 1.17 ).
+	abi0 bool
+	// flagsBlock, flagX and flagY are the operands compared by the last instruction that sets the flags in flagsBlock.
+	flagsBlock   *BasicBlock
+	flagX, flagY Value
+}
+
+var (
+	x86GPRegs = [...]string{"AX", "CX", "DX", "BX", "SP", "BP", "SI", "DI", "R8", "R9", "R10", "R11", "R12", "R13", "R14", "R15"}
+
+	// x86IntArgRegs and x86FloatArgRegs are the registers for the arguments and the results of ABIInternal in the order of the assignment.
+	x86IntArgRegs   = []string{"AX", "BX", "CX", "DI", "SI", "R8", "R9", "R10", "R11"}
+	x86FloatArgRegs = []string{"X0", "X1", "X2", "X3", "X4", "X5", "X6", "X7", "X8", "X9", "X10", "X11", "X12", "X13", "X14"}
+)
+
+// x86RegVar returns the variable name of the register r.
+func x86RegVar(r x86asm.Reg) string {
+	switch {
+	case x86asm.AL <= r && r <= x86asm.BL:
+		return x86GPRegs[r-x86asm.AL]
+	case x86asm.AH <= r && r <= x86asm.BH:
+		return x86GPRegs[r-x86asm.AH]
+	case x86asm.SPB <= r && r <= x86asm.R15B:
+		return x86GPRegs[r-x86asm.SPB+4]
+	case x86asm.AX <= r && r <= x86asm.R15W:
+		return x86GPRegs[r-x86asm.AX]
+	case x86asm.EAX <= r && r <= x86asm.R15L:
+		return x86GPRegs[r-x86asm.EAX]
+	case x86asm.RAX <= r && r <= x86asm.R15:
+		return x86GPRegs[r-x86asm.RAX]
+	case x86asm.X0 <= r && r <= x86asm.X15:
+		return fmt.Sprintf("X%d", r-x86asm.X0)
+	}
+	return r.String()
+}
+
+// registerABI reports whether the arguments are passed in the registers.
+func (m *x86Machine) registerABI() bool {
+	return m.mode == 64 && !m.abi0
+}
+
+func x86Args(raw x86asm.Inst) []x86asm.Arg {
+	var args []x86asm.Arg
+	for _, arg := range raw.Args {
+		if arg == nil {
+			break
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
+func (m *x86Machine) liftInst(l *lifter, inst *arch.Inst) {
+	raw := inst.Raw.(x86asm.Inst)
+	args := x86Args(raw)
+	switch raw.Op {
+	case x86asm.NOP, x86asm.PUSH:
+		return
+	case x86asm.MOV, x86asm.MOVZX, x86asm.MOVSX, x86asm.MOVSXD, x86asm.MOVQ, x86asm.MOVD,
+		x86asm.MOVSD_XMM, x86asm.MOVSS, x86asm.MOVUPS, x86asm.MOVAPS, x86asm.MOVUPD, x86asm.MOVAPD,
+		x86asm.MOVDQU, x86asm.MOVDQA:
+		m.write(l, inst, args[0], m.read(l, inst, args[1]))
+		return
+	case x86asm.LEA:
+		if mem, ok := args[1].(x86asm.Mem); ok {
+			m.write(l, inst, args[0], m.address(l, inst, mem))
+			return
+		}
+	case x86asm.XOR, x86asm.SUB, x86asm.PXOR, x86asm.XORPS, x86asm.XORPD:
+		if x, ok := args[0].(x86asm.Reg); ok && args[1] == x86asm.Arg(x) {
+			zero := &Const{}
+			m.write(l, inst, x, zero)
+			m.setFlags(l, zero, zero)
+			return
+		}
+	}
+	if op, ok := x86BinOps[raw.Op]; ok && len(args) >= 2 {
+		x, y := args[0], args[1]
+		if raw.Op == x86asm.IMUL && len(args) == 3 {
+			x, y = args[1], args[2]
+		}
+		v := &BinOp{Op: op, X: m.read(l, inst, x), Y: m.read(l, inst, y), Unsigned: raw.Op == x86asm.SHR}
+		l.emit(v, inst.PC)
+		m.write(l, inst, args[0], v)
+		if raw.Op == x86asm.SUB {
+			m.setFlags(l, v.X, v.Y)
+		} else {
+			m.setFlags(l, v, &Const{})
+		}
+		return
+	}
+	switch raw.Op {
+	case x86asm.INC, x86asm.DEC:
+		op := token.ADD
+		if raw.Op == x86asm.DEC {
+			op = token.SUB
+		}
+		v := &BinOp{Op: op, X: m.read(l, inst, args[0]), Y: &Const{Value: 1}}
+		l.emit(v, inst.PC)
+		m.write(l, inst, args[0], v)
+		m.setFlags(l, v, &Const{})
+		return
+	case x86asm.NEG, x86asm.NOT:
+		op := token.SUB
+		if raw.Op == x86asm.NOT {
+			op = token.XOR
+		}
+		v := &UnOp{Op: op, X: m.read(l, inst, args[0])}
+		l.emit(v, inst.PC)
+		m.write(l, inst, args[0], v)
+		m.setFlags(l, v, &Const{})
+		return
+	case x86asm.CMP:
+		m.setFlags(l, m.read(l, inst, args[0]), m.read(l, inst, args[1]))
+		return
+	case x86asm.TEST:
+		x := m.read(l, inst, args[0])
+		if args[0] == args[1] {
+			m.setFlags(l, x, &Const{})
+			return
+		}
+		v := &BinOp{Op: token.AND, X: x, Y: m.read(l, inst, args[1])}
+		l.emit(v, inst.PC)
+		m.setFlags(l, v, &Const{})
+		return
+	case x86asm.CALL:
+		m.call(l, inst)
+		m.setFlags(l, nil, nil)
+		return
+	}
+	// the lifter does not know the semantics, so the destination is computed from the other operands.
+	v := &Opaque{Op: inst.Op}
+	for i, arg := range args {
+		if i > 0 {
+			v.Args = append(v.Args, m.read(l, inst, arg))
+		}
+	}
+	l.emit(v, inst.PC)
+	if len(args) > 0 {
+		switch args[0].(type) {
+		case x86asm.Reg, x86asm.Mem:
+			m.write(l, inst, args[0], v)
+		}
+	}
+	m.setFlags(l, nil, nil)
+}
+
+var x86BinOps = map[x86asm.Op]token.Token{
+	x86asm.ADD:   token.ADD,
+	x86asm.SUB:   token.SUB,
+	x86asm.AND:   token.AND,
+	x86asm.OR:    token.OR,
+	x86asm.XOR:   token.XOR,
+	x86asm.IMUL:  token.MUL,
+	x86asm.SHL:   token.SHL,
+	x86asm.SHR:   token.SHR,
+	x86asm.SAR:   token.SHR,
+	x86asm.ADDSD: token.ADD,
+	x86asm.ADDSS: token.ADD,
+	x86asm.SUBSD: token.SUB,
+	x86asm.SUBSS: token.SUB,
+	x86asm.MULSD: token.MUL,
+	x86asm.MULSS: token.MUL,
+	x86asm.DIVSD: token.QUO,
+	x86asm.DIVSS: token.QUO,
+}
+
+// x86CondOps is the comparison of the conditional jumps. The unsigned comparisons are in x86UnsignedCondOps.
+var (
+	x86CondOps = map[x86asm.Op]token.Token{
+		x86asm.JE:  token.EQL,
+		x86asm.JNE: token.NEQ,
+		x86asm.JL:  token.LSS,
+		x86asm.JLE: token.LEQ,
+		x86asm.JG:  token.GTR,
+		x86asm.JGE: token.GEQ,
+	}
+	x86UnsignedCondOps = map[x86asm.Op]token.Token{
+		x86asm.JB:  token.LSS,
+		x86asm.JBE: token.LEQ,
+		x86asm.JA:  token.GTR,
+		x86asm.JAE: token.GEQ,
+	}
+)
+
+func (m *x86Machine) liftTerminator(l *lifter, inst *arch.Inst, kind binarycfg.Kind) {
+	raw := inst.Raw.(x86asm.Inst)
+	switch kind {
+	case binarycfg.Jump:
+		l.emit(&Jump{}, inst.PC)
+	case binarycfg.If:
+		if len(l.cur.Succs) == 1 {
+			l.emit(&Jump{}, inst.PC)
+			return
+		}
+		var cond Value
+		op, ok := x86CondOps[raw.Op]
+		unsignedOp, unsigned := x86UnsignedCondOps[raw.Op]
+		if unsigned {
+			op, ok = unsignedOp, true
+		}
+		if ok && m.flagsBlock == l.cur && m.flagX != nil {
+			v := &BinOp{Op: op, X: m.flagX, Y: m.flagY, Unsigned: unsigned}
+			v.typ = types.Typ[types.Bool]
+			l.emit(v, inst.PC)
+			cond = v
+		} else {
+			v := &Opaque{Op: inst.Op}
+			l.emit(v, inst.PC)
+			cond = v
+		}
+		l.emit(&If{Cond: cond}, inst.PC)
+	case binarycfg.TailCall:
+		call := m.call(l, inst)
+		l.emit(&Return{Results: m.results(l, m.signature(call.Func))}, inst.PC)
+	case binarycfg.IndirectJump, binarycfg.JumpTable:
+		l.emit(&IndirectJump{Target: m.read(l, inst, raw.Args[0])}, inst.PC)
+	case binarycfg.Return:
+		l.emit(&Return{Results: m.results(l, l.fn.Signature)}, inst.PC)
+	case binarycfg.Trap:
+		l.emit(&Trap{Op: raw.String()}, inst.PC)
+	}
+}
+
+func (m *x86Machine) setFlags(l *lifter, x, y Value) {
+	m.flagsBlock, m.flagX, m.flagY = l.cur, x, y
+}
+
+// call appends the call of inst ( CALL or JMP to another function ) and defines the result registers.
+func (m *x86Machine) call(l *lifter, inst *arch.Inst) *Call {
+	raw := inst.Raw.(x86asm.Inst)
+	call := &Call{}
+	target, ok := l.arc.CallTarget(inst)
+	if !ok && raw.Op == x86asm.JMP {
+		target, ok = l.arc.BranchTarget(inst)
+	}
+	if ok {
+		call.Addr = target
+		if l.callee != nil {
+			call.Func = l.callee(target)
+		}
+	} else {
+		call.Value = m.read(l, inst, raw.Args[0])
+	}
+	sig := m.signature(call.Func)
+	for _, reg := range m.assign(sig.Recv(), sig.Params()) {
+		call.Args = append(call.Args, l.read(reg.name))
+	}
+	results := m.assign(nil, sig.Results())
+	if len(results) > 0 {
+		call.typ = results[0].typ
+	}
+	l.emit(call, inst.PC)
+	// the callee may clobber all registers, but the results are the only values that the code reads after the call.
+	l.write("AX", call)
+	for i, reg := range results {
+		if i == 0 {
+			l.write(reg.name, call)
+			continue
+		}
+		v := &Extract{Call: call, Index: i}
+		v.typ = reg.typ
+		l.emit(v, inst.PC)
+		l.write(reg.name, v)
+	}
+	return call
+}
+
+// results returns the values of the result registers of sig.
+func (m *x86Machine) results(l *lifter, sig *types.Signature) []Value {
+	var results []Value
+	for _, reg := range m.assign(nil, sig.Results()) {
+		results = append(results, l.read(reg.name))
+	}
+	return results
+}
+
+// signature returns the signature of fn if the arguments are passed in the registers.
+func (m *x86Machine) signature(fn *ssa.Function) *types.Signature {
+	if fn == nil || fn.Signature == nil || !m.registerABI() {
+		return types.NewSignature(nil, nil, nil, false)
+	}
+	return fn.Signature
+}
+
+func (m *x86Machine) paramOrder(v string) int {
+	for i, reg := range x86IntArgRegs {
+		if reg == v {
+			return i
+		}
+	}
+	for i, reg := range x86FloatArgRegs {
+		if reg == v {
+			return len(x86IntArgRegs) + i
+		}
+	}
+	return len(x86IntArgRegs) + len(x86FloatArgRegs)
+}
+
+func (m *x86Machine) paramTypes(sig *types.Signature) map[string]types.Type {
+	if !m.registerABI() {
+		return nil
+	}
+	typs := map[string]types.Type{}
+	for _, reg := range m.assign(sig.Recv(), sig.Params()) {
+		if reg.typ != nil {
+			typs[reg.name] = reg.typ
+		}
+	}
+	return typs
+}
+
+// abiReg is a register assigned to a value by ABIInternal.
+type abiReg struct {
+	name string
+	// typ is the type of the value if the value is in the register alone.
+	typ types.Type
+}
+
+// assign assigns the registers of ABIInternal to recv and the values of tuple in order.
+func (m *x86Machine) assign(recv *types.Var, tuple *types.Tuple) []abiReg {
+	vars := tupleVars(recv, tuple)
+	var regs []abiReg
+	for i, names := range m.assignVars(vars) {
+		for _, name := range names {
+			reg := abiReg{name: name}
+			if len(names) == 1 {
+				reg.typ = vars[i].Type()
+			}
+			regs = append(regs, reg)
+		}
+	}
+	return regs
+}
+
+// assignVars returns the registers of ABIInternal for each of vars. They are nil for the values passed on the stack.
+// A value that does not fit into the remaining registers is passed on the stack, and the later values may still use the registers.
+func (m *x86Machine) assignVars(vars []*types.Var) [][]string {
+	if !m.registerABI() {
+		return make([][]string, len(vars))
+	}
+	var (
+		regs         = make([][]string, 0, len(vars))
+		ints, floats int
+	)
+	for _, v := range vars {
+		var names []string
+		savedInts, savedFloats := ints, floats
+		if !x86AssignRegs(v.Type(), &ints, &floats, &names) {
+			ints, floats = savedInts, savedFloats
+			names = nil
+		}
+		regs = append(regs, names)
+	}
+	return regs
+}
+
+func tupleVars(recv *types.Var, tuple *types.Tuple) []*types.Var {
+	var vars []*types.Var
+	if recv != nil {
+		vars = append(vars, recv)
+	}
+	for i := 0; i < tuple.Len(); i++ {
+		vars = append(vars, tuple.At(i))
+	}
+	return vars
+}
+
+func (m *x86Machine) abiParams(l *lifter, argsSize int) ([]*Param, []*Param) {
+	sig := l.fn.Signature
+	if sig.Recv() != nil || sig.Params().Len() > 0 || sig.Results().Len() > 0 {
+		params, results := tupleVars(sig.Recv(), sig.Params()), tupleVars(nil, sig.Results())
+		return newParams("arg", params, m.assignVars(params)), newParams("r", results, m.assignVars(results))
+	}
+	ptrSize := m.mode / 8
+	if !m.registerABI() {
+		// all arguments are passed on the stack, and the args size includes the results.
+		var args []*Param
+		for i := 0; i < argsSize/ptrSize; i++ {
+			args = append(args, &Param{Name: fmt.Sprintf("arg%d", i)})
+		}
+		return args, nil
+	}
+	// the registers are assigned in order, so the registers before the last one read are the arguments too.
+	ints, floats := 0, 0
+	for _, p := range l.fn.Params {
+		if i := indexOf(x86IntArgRegs, p.Var); i >= ints {
+			ints = i + 1
+		}
+		if i := indexOf(x86FloatArgRegs, p.Var); i >= floats {
+			floats = i + 1
+		}
+	}
+	// the args size includes the spill slots of the arguments in the registers, and the arguments never read use them too.
+	if words := argsSize / ptrSize; words > ints+floats {
+		ints = words - floats
+		if ints > len(x86IntArgRegs) {
+			ints = len(x86IntArgRegs)
+		}
+	}
+	var args []*Param
+	for _, reg := range append(append([]string{}, x86IntArgRegs[:ints]...), x86FloatArgRegs[:floats]...) {
+		args = append(args, &Param{Name: fmt.Sprintf("arg%d", len(args)), Regs: []string{reg}})
+	}
+	// the float registers are not regarded as the results if the integer registers are, because they are often the scratch registers.
+	var results []*Param
+	for _, regs := range [][]string{x86IntArgRegs, x86FloatArgRegs} {
+		for _, reg := range regs {
+			if !l.returnsComputed(reg) {
+				break
+			}
+			results = append(results, &Param{Name: fmt.Sprintf("r%d", len(results)), Regs: []string{reg}})
+		}
+		if len(results) > 0 {
+			break
+		}
+	}
+	return args, results
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// x86AssignRegs appends the registers for a value of typ to names. It returns false if the value does not fit.
+func x86AssignRegs(typ types.Type, ints, floats *int, names *[]string) bool {
+	intRegs := func(n int) bool {
+		if *ints+n > len(x86IntArgRegs) {
+			return false
+		}
+		*names = append(*names, x86IntArgRegs[*ints:*ints+n]...)
+		*ints += n
+		return true
+	}
+	floatRegs := func(n int) bool {
+		if *floats+n > len(x86FloatArgRegs) {
+			return false
+		}
+		*names = append(*names, x86FloatArgRegs[*floats:*floats+n]...)
+		*floats += n
+		return true
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsComplex != 0:
+			return floatRegs(2)
+		case t.Info()&types.IsFloat != 0:
+			return floatRegs(1)
+		case t.Kind() == types.String:
+			return intRegs(2)
+		}
+		return intRegs(1)
+	case *types.Slice:
+		return intRegs(3)
+	case *types.Interface:
+		return intRegs(2)
+	case *types.Array:
+		switch t.Len() {
+		case 0:
+			return true
+		case 1:
+			return x86AssignRegs(t.Elem(), ints, floats, names)
+		}
+		return false
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			if !x86AssignRegs(t.Field(i).Type(), ints, floats, names) {
+				return false
+			}
+		}
+		return true
+	}
+	// pointers, maps, channels and funcs.
+	return intRegs(1)
+}
+
+// read returns the value of the operand arg.
+func (m *x86Machine) read(l *lifter, inst *arch.Inst, arg x86asm.Arg) Value {
+	switch a := arg.(type) {
+	case x86asm.Reg:
+		return l.read(x86RegVar(a))
+	case x86asm.Imm:
+		return &Const{Value: int64(a)}
+	case x86asm.Rel:
+		return &Const{Value: int64(inst.PC) + int64(inst.Len) + int64(a)}
+	case x86asm.Mem:
+		if slot, ok := m.stackSlot(a); ok {
+			return l.read(slot)
+		}
+		v := &Load{Addr: m.address(l, inst, a)}
+		l.emit(v, inst.PC)
+		return v
+	}
+	v := &Opaque{Op: fmt.Sprint(arg)}
+	l.emit(v, inst.PC)
+	return v
+}
+
+// write assigns v to the operand arg.
+func (m *x86Machine) write(l *lifter, inst *arch.Inst, arg x86asm.Arg, v Value) {
+	switch a := arg.(type) {
+	case x86asm.Reg:
+		l.write(x86RegVar(a), v)
+	case x86asm.Mem:
+		if slot, ok := m.stackSlot(a); ok {
+			l.write(slot, v)
+			return
+		}
+		l.emit(&Store{Addr: m.address(l, inst, a), Val: v}, inst.PC)
+	}
+}
+
+// stackSlot returns the variable name of the memory mem if it is a stack slot.
+func (m *x86Machine) stackSlot(mem x86asm.Mem) (string, bool) {
+	if (mem.Base != x86asm.RSP && mem.Base != x86asm.ESP) || mem.Index != 0 || mem.Segment != 0 {
+		return "", false
+	}
+	return fmt.Sprintf("SP+%d", m.disp(mem)), true
+}
+
+// disp returns the displacement of mem sign-extended to the address size.
+func (m *x86Machine) disp(mem x86asm.Mem) int64 {
+	if m.mode == 32 {
+		return int64(int32(mem.Disp))
+	}
+	return mem.Disp
+}
+
+// address returns the address of the memory operand mem.
+func (m *x86Machine) address(l *lifter, inst *arch.Inst, mem x86asm.Mem) Value {
+	if mem.Base == x86asm.RIP {
+		return &Const{Value: int64(inst.PC) + int64(inst.Len) + mem.Disp}
+	}
+	var addr Value
+	add := func(v Value) {
+		if addr == nil {
+			addr = v
+			return
+		}
+		sum := &BinOp{Op: token.ADD, X: addr, Y: v}
+		l.emit(sum, inst.PC)
+		addr = sum
+	}
+	if mem.Base != 0 {
+		add(l.read(x86RegVar(mem.Base)))
+	}
+	if mem.Index != 0 {
+		var index Value = l.read(x86RegVar(mem.Index))
+		if mem.Scale > 1 {
+			scaled := &BinOp{Op: token.MUL, X: index, Y: &Const{Value: int64(mem.Scale)}}
+			l.emit(scaled, inst.PC)
+			index = scaled
+		}
+		add(index)
+	}
+	if disp := m.disp(mem); disp != 0 || addr == nil {
+		add(&Const{Value: disp})
+	}
+	if mem.Segment != 0 {
+		// thread local storage.
+		v := &Opaque{Op: mem.Segment.String(), Args: []Value{addr}}
+		l.emit(v, inst.PC)
+		addr = v
+	}
+	return addr
+}
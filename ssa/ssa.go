@@ -13,21 +13,52 @@ import (
 )
 
 type Builder struct {
-	typeMap map[string]reflect.Type
-	prog    *ssa.Program
+	typeMap    map[string]reflect.Type
+	conv       *binarytypes.Converter
+	prog       *ssa.Program
+	signatures SignatureLookup
 }
 
-func NewBuilder(types []reflect.Type) *Builder {
+// BuilderOption is an option of NewBuilder.
+type BuilderOption func(*Builder)
+
+// SignatureLookup returns the signature of the function whose entry is addr. It returns nil if the signature is unknown.
+type SignatureLookup func(addr uint64) *types.Signature
+
+// WithSignatures gives the signatures of the functions without receivers ( e.g. built from the DWARF ),
+// because the binary has the type metadata of the methods only.
+func WithSignatures(lookup SignatureLookup) BuilderOption {
+	return func(b *Builder) {
+		b.signatures = lookup
+	}
+}
+
+// NewBuilder creates the Builder for the types of a binary.
+// conv converts the types of the signatures, so pass the Converter shared in the binary to preserve the identity of the types.
+// A new Converter is used if conv is nil.
+func NewBuilder(types []reflect.Type, conv *binarytypes.Converter, opts ...BuilderOption) *Builder {
+	if conv == nil {
+		conv = binarytypes.NewConverter()
+	}
 	typeMap := map[string]reflect.Type{}
 	for _, typ := range types {
 		typeMap[fmt.Sprintf("%s.%s", typ.PkgPath(), typ.Name())] = typ
 	}
-	return &Builder{
+	b := &Builder{
 		typeMap: typeMap,
+		conv:    conv,
 		prog:    ssa.NewProgram(nil, 0),
 	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
 }
 
+// BuildFunction builds the ssa.Function of fn that has the signature but no body.
+// The signature of a method is converted from the method type metadata of the receiver type.
+// The signature of a function without receiver is the one given by WithSignatures.
+// Otherwise ( e.g. the receiver type has no methods in the type metadata, or the signature is not given ) it is empty ( func() ).
 func (b *Builder) BuildFunction(fn gosym.Func) *ssa.Function {
 	info := &types.Info{
 		Types:      make(map[ast.Expr]types.TypeAndValue),
@@ -56,12 +87,17 @@ func (b *Builder) functionFromGoSymFunc(fn gosym.Func) *ssa.Function {
 			}
 			mtd, found := foundType.MethodByName(base)
 			if found && mtd.Type != nil {
-				sig := binarytypes.MethodSignatureFromReflectType(foundType, mtd)
+				sig := b.conv.MethodSignatureFromReflectType(foundType, mtd)
 				return b.prog.NewFunction(base, sig, "")
 			}
 		}
 		return b.prog.NewFunction(base, types.NewSignature(nil, nil, nil, false), "")
 	}
+	if b.signatures != nil {
+		if sig := b.signatures(fn.Entry); sig != nil {
+			return b.prog.NewFunction(base, sig, "")
+		}
+	}
 	return b.prog.NewFunction(base, types.NewSignature(nil, nil, nil, false), "")
 }
 
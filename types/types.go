@@ -4,33 +4,179 @@ import (
 	"fmt"
 	"go/token"
 	"go/types"
+	"path"
+	"strings"
+	"sync"
 
+	internalreflect "github.com/goccy/binarian/internal/reflect"
 	"github.com/goccy/binarian/reflect"
 )
 
-type wrappedType struct {
-	typ    types.Type
-	cached *string
-	rtype  reflect.Type
+// Converter converts reflect.Type to types.Type.
+// It caches the converted types by their addresses and the packages by their paths,
+// so the types converted by the same Converter preserve their identity ( e.g. types.Identical reports true for the same type ).
+// Use one Converter for all types of a binary. It is safe for concurrent use.
+type Converter struct {
+	mu    sync.Mutex
+	types map[uintptr]types.Type
+	pkgs  map[string]*types.Package
 }
 
-func (t *wrappedType) Underlying() types.Type {
-	return t.typ
+func NewConverter() *Converter {
+	return &Converter{
+		types: map[uintptr]types.Type{},
+		pkgs:  map[string]*types.Package{},
+	}
+}
+
+// TypeFromReflectType returns the types.Type of typ.
+// A named type is converted to *types.Named in the package of its PkgPath with the methods in the binary.
+func (c *Converter) TypeFromReflectType(typ reflect.Type) types.Type {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.typeFromReflectType(typ)
+}
+
+// MethodSignatureFromReflectType returns the signature of the method mtd of recv.
+func (c *Converter) MethodSignatureFromReflectType(recv reflect.Type, mtd reflect.Method) *types.Signature {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.signatureFromReflectType(
+		types.NewVar(token.NoPos, nil, "", c.typeFromReflectType(recv)),
+		mtd.Type,
+	)
+}
+
+// SignatureFromReflectType returns the signature of the func type typ.
+func (c *Converter) SignatureFromReflectType(typ reflect.Type) (*types.Signature, error) {
+	if typ.Kind() != reflect.Func {
+		return nil, fmt.Errorf("failed to convert from reflect.Type to *types.Func. from type is %s", typ.Kind())
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.signatureFromReflectType(nil, typ), nil
+}
+
+// StructTypeFromReflectType returns the types.Type of the struct type typ. It is *types.Named if typ is a named type.
+func (c *Converter) StructTypeFromReflectType(typ reflect.Type) (types.Type, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("failed to convert from reflect.Type to *types.Struct. from type is %s", typ.Kind())
+	}
+	return c.TypeFromReflectType(typ), nil
+}
+
+// Package returns the package of pkgPath that the converted types belong to. It returns nil if pkgPath is empty.
+func (c *Converter) Package(pkgPath string) *types.Package {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pkg(pkgPath, "")
+}
+
+// Declare inserts obj into the scope of its package unless the scope has an object of the same name, and returns the object that it has.
+// The conversion of the named types inserts them into the scopes, so the other objects must be inserted with Declare for concurrent use.
+func (c *Converter) Declare(obj types.Object) types.Object {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return obj.Pkg().Scope().Insert(obj)
 }
 
-func (t *wrappedType) String() string {
-	return t.rtype.String()
+// pkg returns the package of path. name is the package name, and the last element of path is used if it is empty.
+func (c *Converter) pkg(pkgPath, name string) *types.Package {
+	if pkgPath == "" {
+		return nil
+	}
+	if pkg, exists := c.pkgs[pkgPath]; exists {
+		return pkg
+	}
+	if name == "" {
+		name = path.Base(pkgPath)
+	}
+	pkg := types.NewPackage(pkgPath, name)
+	c.pkgs[pkgPath] = pkg
+	return pkg
 }
 
+// TypeFromReflectType returns the types.Type of typ with a new Converter.
+// Use Converter to convert multiple types of the same binary.
 func TypeFromReflectType(typ reflect.Type) types.Type {
-	cachedMap := map[string]types.Type{}
-	return typeFromReflectType(typ, cachedMap)
+	return NewConverter().TypeFromReflectType(typ)
 }
 
-func typeFromReflectType(typ reflect.Type, cachedMap map[string]types.Type) types.Type {
-	if t, found := cachedMap[typ.String()]; found {
+func (c *Converter) typeFromReflectType(typ reflect.Type) types.Type {
+	if t, found := c.types[typ.Addr()]; found {
 		return t
 	}
+	// unsafe.Pointer is predeclared in go/types.
+	if typ.Name() != "" && typ.Kind() != reflect.UnsafePointer {
+		if typ.PkgPath() != "" {
+			return c.namedTypeFromReflectType(typ)
+		}
+		if typ.Kind() == reflect.Interface && typ.Name() == "error" {
+			return types.Universe.Lookup("error").Type()
+		}
+	}
+	t := c.underlyingTypeFromReflectType(typ)
+	c.types[typ.Addr()] = t
+	return t
+}
+
+// namedTypeFromReflectType returns *types.Named of typ.
+// The methods of typ are declared with the value receiver and the methods only *typ has are declared with the pointer receiver.
+// The methods promoted from the embedded fields and the methods removed by the linker are not declared.
+func (c *Converter) namedTypeFromReflectType(typ reflect.Type) types.Type {
+	// the package name is the qualifier of the type name ( e.g. yaml.Node for gopkg.in/yaml.v3 ).
+	pkgName := strings.TrimSuffix(typ.String(), "."+typ.Name())
+	if strings.ContainsAny(pkgName, ".*[") {
+		pkgName = ""
+	}
+	pkg := c.pkg(typ.PkgPath(), pkgName)
+	obj := types.NewTypeName(token.NoPos, pkg, typ.Name(), nil)
+	named := types.NewNamed(obj, nil, nil)
+	pkg.Scope().Insert(obj)
+	// cache it before the conversion of the underlying type and the methods, because they can refer to the named type itself
+	// ( e.g. type Node struct{ Next *Node } ).
+	c.types[typ.Addr()] = named
+	named.SetUnderlying(c.underlyingTypeFromReflectType(typ))
+	if typ.Kind() == reflect.Interface {
+		// the methods of an interface type belong to the underlying interface.
+		return named
+	}
+	declared := map[string]struct{}{}
+	c.addMethods(named, typ.AllMethods(), named, declared)
+	// the method set of *T is known only for the types loaded from the binary.
+	if t, ok := typ.(*internalreflect.Type); ok {
+		if ptr := internalreflect.PtrTo(t); ptr != nil {
+			c.addMethods(named, ptr.AllMethods(), types.NewPointer(named), declared)
+		}
+	}
+	return named
+}
+
+func (c *Converter) addMethods(named *types.Named, methods []reflect.Method, recv types.Type, declared map[string]struct{}) {
+	pkg := named.Obj().Pkg()
+	for _, m := range methods {
+		if m.Type == nil {
+			continue
+		}
+		if _, exists := declared[m.Name]; exists {
+			continue
+		}
+		declared[m.Name] = struct{}{}
+		mpkg := pkg
+		if m.PkgPath != "" {
+			mpkg = c.pkg(m.PkgPath, "")
+		}
+		if obj, _, _ := types.LookupFieldOrMethod(named.Underlying(), true, mpkg, m.Name); obj != nil {
+			// promoted from an embedded field.
+			continue
+		}
+		sig := c.signatureFromReflectType(types.NewVar(token.NoPos, pkg, "", recv), m.Type)
+		named.AddMethod(types.NewFunc(token.NoPos, mpkg, m.Name, sig))
+	}
+}
+
+// underlyingTypeFromReflectType returns the type literal of typ without its name.
+func (c *Converter) underlyingTypeFromReflectType(typ reflect.Type) types.Type {
 	switch typ.Kind() {
 	case reflect.Bool:
 		return types.Typ[types.Bool]
@@ -65,54 +211,29 @@ func typeFromReflectType(typ reflect.Type, cachedMap map[string]types.Type) type
 	case reflect.Complex128:
 		return types.Typ[types.Complex128]
 	case reflect.Array:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		w.typ = types.NewArray(typeFromReflectType(typ.Elem(), cachedMap), int64(typ.Len()))
-		return w.typ
+		return types.NewArray(c.typeFromReflectType(typ.Elem()), int64(typ.Len()))
 	case reflect.Chan:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		w.typ = types.NewChan(types.ChanDir(typ.ChanDir()), typeFromReflectType(typ.Elem(), cachedMap))
-		return w.typ
+		return types.NewChan(types.ChanDir(typ.ChanDir()), c.typeFromReflectType(typ.Elem()))
 	case reflect.Func:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		w.typ = signatureFromReflectType(nil, typ, cachedMap)
-		return w.typ
+		return c.signatureFromReflectType(nil, typ)
 	case reflect.Interface:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
 		methods := make([]*types.Func, typ.NumMethod())
 		for i := 0; i < typ.NumMethod(); i++ {
 			mtd := typ.Method(i)
-			sig := signatureFromReflectType(nil, mtd.Type, cachedMap)
-			methods[i] = types.NewFunc(token.NoPos, nil, mtd.Name, sig)
+			sig := c.signatureFromReflectType(nil, mtd.Type)
+			methods[i] = types.NewFunc(token.NoPos, c.pkg(mtd.PkgPath, ""), mtd.Name, sig)
 		}
-		w.typ = types.NewInterfaceType(methods, nil)
-		return w.typ
+		return types.NewInterfaceType(methods, nil)
 	case reflect.Map:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		w.typ = types.NewMap(typeFromReflectType(typ.Key(), cachedMap), typeFromReflectType(typ.Elem(), cachedMap))
-		return w.typ
+		return types.NewMap(c.typeFromReflectType(typ.Key()), c.typeFromReflectType(typ.Elem()))
 	case reflect.Ptr:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		w.typ = types.NewPointer(typeFromReflectType(typ.Elem(), cachedMap))
-		return w.typ
+		return types.NewPointer(c.typeFromReflectType(typ.Elem()))
 	case reflect.Slice:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		w.typ = types.NewSlice(typeFromReflectType(typ.Elem(), cachedMap))
-		return w.typ
+		return types.NewSlice(c.typeFromReflectType(typ.Elem()))
 	case reflect.String:
 		return types.Typ[types.String]
 	case reflect.Struct:
-		w := &wrappedType{rtype: typ}
-		cachedMap[typ.String()] = w
-		t, _ := structTypeFromReflectType(typ, cachedMap)
-		w.typ = t
-		return t
+		return c.structTypeFromReflectType(typ)
 	case reflect.UnsafePointer:
 		return types.Typ[types.UnsafePointer]
 	}
@@ -120,60 +241,46 @@ func typeFromReflectType(typ reflect.Type, cachedMap map[string]types.Type) type
 }
 
 func MethodSignatureFromReflectType(recv reflect.Type, mtd reflect.Method) *types.Signature {
-	cachedMap := map[string]types.Type{}
-	return signatureFromReflectType(
-		types.NewVar(token.NoPos, nil, "", typeFromReflectType(recv, cachedMap)),
-		mtd.Type,
-		cachedMap,
-	)
+	return NewConverter().MethodSignatureFromReflectType(recv, mtd)
 }
 
 func SignatureFromReflectType(typ reflect.Type) (*types.Signature, error) {
-	if typ.Kind() != reflect.Func {
-		return nil, fmt.Errorf("failed to convert from reflect.Type to *types.Func. from type is %s", typ.Kind())
-	}
-	return signatureFromReflectType(nil, typ, map[string]types.Type{}), nil
+	return NewConverter().SignatureFromReflectType(typ)
 }
 
-func signatureFromReflectType(recv *types.Var, typ reflect.Type, cachedMap map[string]types.Type) *types.Signature {
+func (c *Converter) signatureFromReflectType(recv *types.Var, typ reflect.Type) *types.Signature {
 	params := make([]*types.Var, 0, typ.NumIn())
 	for i := 0; i < typ.NumIn(); i++ {
-		params = append(params, types.NewParam(token.NoPos, nil, "", typeFromReflectType(typ.In(i), cachedMap)))
+		params = append(params, types.NewParam(token.NoPos, nil, "", c.typeFromReflectType(typ.In(i))))
 	}
 	paramTuple := types.NewTuple(params...)
 	results := make([]*types.Var, 0, typ.NumOut())
 	for i := 0; i < typ.NumOut(); i++ {
-		results = append(results, types.NewVar(token.NoPos, nil, "", typeFromReflectType(typ.Out(i), cachedMap)))
+		results = append(results, types.NewVar(token.NoPos, nil, "", c.typeFromReflectType(typ.Out(i))))
 	}
 	resultTuple := types.NewTuple(results...)
 	return types.NewSignature(recv, paramTuple, resultTuple, typ.IsVariadic())
 }
 
 func StructTypeFromReflectType(typ reflect.Type) (types.Type, error) {
-	return structTypeFromReflectType(typ, map[string]types.Type{})
+	return NewConverter().StructTypeFromReflectType(typ)
 }
 
-func structTypeFromReflectType(typ reflect.Type, cachedMap map[string]types.Type) (types.Type, error) {
-	if typ.Kind() != reflect.Struct {
-		return nil, fmt.Errorf("failed to convert from reflect.Type to *types.Struct. from type is %s", typ.Kind())
-	}
+func (c *Converter) structTypeFromReflectType(typ reflect.Type) *types.Struct {
 	fields := make([]*types.Var, 0, typ.NumField())
+	tags := make([]string, 0, typ.NumField())
 	for i := 0; i < typ.NumField(); i++ {
 		structField := typ.Field(i)
 		if structField.Type == nil {
-			// unexported field
 			continue
 		}
-		fields = append(fields, types.NewVar(token.NoPos, nil, structField.Name, typeFromReflectType(structField.Type, cachedMap)))
-	}
-	name := typ.Name()
-	if name != "" {
-		s := types.NewStruct(fields, nil)
-		return types.NewNamed(
-			types.NewTypeName(token.NoPos, nil, name, s),
-			s,
-			nil,
-		), nil
-	}
-	return types.NewStruct(fields, nil), nil
+		// exported fields belong to the package that declares the struct type too.
+		pkgPath := structField.PkgPath
+		if pkgPath == "" {
+			pkgPath = typ.PkgPath()
+		}
+		fields = append(fields, types.NewField(token.NoPos, c.pkg(pkgPath, ""), structField.Name, c.typeFromReflectType(structField.Type), structField.Anonymous))
+		tags = append(tags, string(structField.Tag))
+	}
+	return types.NewStruct(fields, tags)
 }